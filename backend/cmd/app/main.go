@@ -7,24 +7,63 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
+	"flip-test/internal/fx"
 	"flip-test/internal/handler"
 	"flip-test/internal/middleware"
+	"flip-test/internal/parser"
 	"flip-test/internal/repository"
+	"flip-test/internal/scheduler"
 	"flip-test/internal/service"
 )
 
 func main() {
-	transactionRepository := repository.NewTransactionRepository()
-	transactionService := service.NewTransactionService(transactionRepository)
-	transactionHandler := handler.NewTransactionHandler(transactionService)
+	transactionStore, closeStore := getTransactionStore()
+	uploadStore := getUploadStore()
+	issueStore := getIssueStore()
+	transactionService := service.NewTransactionService(transactionStore)
+	transactionService.RateTable = getRateTable()
+	transactionService.OverdraftFloor = getOverdraftFloor()
+	transactionService.AgingBuckets = getAgingBuckets()
+	transactionService.PendingExpiryDays = getPendingExpiryDays()
+	uploadService := service.NewUploadService(uploadStore, transactionService)
+	counterpartyService := service.NewCounterpartyService(transactionStore)
+	issueService := service.NewIssueService(issueStore, transactionService)
+	reconciliationService := service.NewReconciliationService(transactionStore)
+	parseOptions := getParseOptions()
+	transactionHandler := handler.NewTransactionHandler(transactionService, uploadService, parseOptions)
+	uploadHandler := handler.NewUploadHandler(uploadService)
+	counterpartyHandler := handler.NewCounterpartyHandler(counterpartyService)
+	issueHandler := handler.NewIssueHandler(issueService)
+	reconciliationHandler := handler.NewReconciliationHandler(reconciliationService, parseOptions)
 
 	mux := http.NewServeMux()
+	mux.HandleFunc("GET /transactions", transactionHandler.ListTransactions)
 	mux.HandleFunc("POST /transactions/upload", transactionHandler.UploadCSV)
+	mux.HandleFunc("POST /transactions/reconcile", reconciliationHandler.Reconcile)
 	mux.HandleFunc("GET /transactions/balance", transactionHandler.GetBalance)
+	mux.HandleFunc("GET /transactions/balance/breakdown", transactionHandler.GetBalanceBreakdown)
+	mux.HandleFunc("GET /transactions/balance/series", transactionHandler.GetBalanceSeries)
+	mux.HandleFunc("GET /transactions/balance/overdrafts", transactionHandler.GetOverdrafts)
+	mux.HandleFunc("GET /transactions/balance/consolidated", transactionHandler.GetConsolidatedBalance)
+	mux.HandleFunc("PATCH /transactions/{id}/status", transactionHandler.UpdateStatus)
+	mux.HandleFunc("GET /transactions/reversals", transactionHandler.GetReversals)
+	mux.HandleFunc("GET /transactions/pending/aging", transactionHandler.GetPendingAging)
+	mux.HandleFunc("POST /transactions/pending/expire", transactionHandler.ExpirePending)
 	mux.HandleFunc("GET /transactions/issues", transactionHandler.GetUnsuccessfulTransactions)
+	mux.HandleFunc("GET /uploads", uploadHandler.GetUploads)
+	mux.HandleFunc("GET /uploads/{id}", uploadHandler.GetUpload)
+	mux.HandleFunc("DELETE /uploads/{id}", uploadHandler.RollbackUpload)
+	mux.HandleFunc("GET /issues", issueHandler.GetIssues)
+	mux.HandleFunc("GET /issues/{id}", issueHandler.GetIssue)
+	mux.HandleFunc("PATCH /issues/{id}", issueHandler.UpdateIssue)
+	mux.HandleFunc("GET /counterparties", counterpartyHandler.GetCounterparties)
+	mux.HandleFunc("GET /counterparties/suggestions", counterpartyHandler.SuggestMerges)
+	mux.HandleFunc("GET /counterparties/{name}/ledger", counterpartyHandler.GetLedger)
 
 	handler := middleware.Chain(
 		middleware.LoggingMiddleware,
@@ -39,6 +78,9 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	jobs := getScheduler(transactionService)
+	jobs.Start()
+
 	go func() {
 		log.Printf("Server starting on %s", server.Addr)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -46,7 +88,11 @@ func main() {
 		}
 	}()
 
-	gracefulShutdown(server)
+	gracefulShutdown(server, jobs)
+
+	if err := closeStore(); err != nil {
+		log.Printf("Failed to close transaction store: %v", err)
+	}
 }
 
 func getServerAddr() string {
@@ -57,7 +103,243 @@ func getServerAddr() string {
 	return fmt.Sprintf(":%s", port)
 }
 
-func gracefulShutdown(server *http.Server) {
+// getTransactionStore picks the storage backend from STORE ("memory" or
+// "file"). The file store keeps its log and snapshots in STORE_PATH.
+func getTransactionStore() (repository.TransactionStore, func() error) {
+	switch os.Getenv("STORE") {
+	case "", "memory":
+		return repository.NewTransactionRepository(), func() error { return nil }
+	case "file":
+		path := getStorePath()
+
+		snapshotEvery := 100
+		if value := os.Getenv("STORE_SNAPSHOT_EVERY"); value != "" {
+			var err error
+			snapshotEvery, err = strconv.Atoi(value)
+			if err != nil {
+				log.Fatalf("Invalid STORE_SNAPSHOT_EVERY: %v", err)
+			}
+		}
+
+		store, err := repository.NewFileTransactionStore(path, snapshotEvery)
+		if err != nil {
+			log.Fatalf("Failed to open file store: %v", err)
+		}
+
+		log.Printf("Using file transaction store in %s", path)
+		return store, store.Close
+	default:
+		log.Fatalf("Invalid STORE: %s", os.Getenv("STORE"))
+		return nil, nil
+	}
+}
+
+// getUploadStore keeps upload records in STORE_PATH too when STORE is
+// "file", so the upload IDs of stored transactions survive a restart.
+func getUploadStore() repository.UploadStore {
+	if os.Getenv("STORE") != "file" {
+		return repository.NewUploadRepository()
+	}
+
+	store, err := repository.NewFileUploadStore(getStorePath())
+	if err != nil {
+		log.Fatalf("Failed to open upload store: %v", err)
+	}
+	return store
+}
+
+// getIssueStore keeps issue states, assignees and notes in STORE_PATH too
+// when STORE is "file".
+func getIssueStore() repository.IssueStore {
+	if os.Getenv("STORE") != "file" {
+		return repository.NewIssueRepository()
+	}
+
+	store, err := repository.NewFileIssueStore(getStorePath())
+	if err != nil {
+		log.Fatalf("Failed to open issue store: %v", err)
+	}
+	return store
+}
+
+func getStorePath() string {
+	if path := os.Getenv("STORE_PATH"); path != "" {
+		return path
+	}
+	return "data"
+}
+
+func getParseOptions() parser.Options {
+	aliases, err := parser.ParseAliases(os.Getenv("CSV_COLUMN_ALIASES"))
+	if err != nil {
+		log.Fatalf("Invalid CSV_COLUMN_ALIASES: %v", err)
+	}
+
+	location := time.UTC
+	if name := os.Getenv("DEFAULT_TIMEZONE"); name != "" {
+		location, err = time.LoadLocation(name)
+		if err != nil {
+			log.Fatalf("Invalid DEFAULT_TIMEZONE: %v", err)
+		}
+	}
+
+	locale, err := parser.ParseLocale(os.Getenv("AMOUNT_LOCALE"))
+	if err != nil {
+		log.Fatalf("Invalid AMOUNT_LOCALE: %v", err)
+	}
+
+	return parser.Options{
+		Aliases:          aliases,
+		TimestampLayouts: parser.ParseTimestampLayouts(os.Getenv("TIMESTAMP_LAYOUTS")),
+		Location:         location,
+		Locale:           locale,
+		NameAliases:      getNameAliases(),
+	}
+}
+
+func getNameAliases() map[string]string {
+	path := os.Getenv("NAME_ALIASES_FILE")
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Failed to open NAME_ALIASES_FILE: %v", err)
+	}
+	defer file.Close()
+
+	aliases, err := parser.LoadNameAliases(file)
+	if err != nil {
+		log.Fatalf("Invalid NAME_ALIASES_FILE: %v", err)
+	}
+
+	log.Printf("Loaded %d name aliases from %s", len(aliases), path)
+	return aliases
+}
+
+func getRateTable() *fx.RateTable {
+	path := os.Getenv("FX_RATES_FILE")
+	if path == "" {
+		return nil
+	}
+
+	rateTable, err := fx.LoadRateTable(path)
+	if err != nil {
+		log.Fatalf("Failed to load FX_RATES_FILE: %v", err)
+	}
+	rateTable.MaxAgeDays = getRateMaxAgeDays()
+
+	log.Printf("Loaded FX rates from %s", path)
+	return rateTable
+}
+
+// getRateMaxAgeDays reads FX_RATE_MAX_AGE_DAYS, how many days an FX rate
+// carries forward without a newer one. Zero carries rates forward
+// indefinitely.
+func getRateMaxAgeDays() int {
+	value := os.Getenv("FX_RATE_MAX_AGE_DAYS")
+	if value == "" {
+		return fx.DefaultMaxAgeDays
+	}
+
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		log.Fatalf("Invalid FX_RATE_MAX_AGE_DAYS: %s", value)
+	}
+	return days
+}
+
+// getOverdraftFloor reads OVERDRAFT_FLOOR, the minimum balance in minor
+// units below which overdrafts are reported.
+func getOverdraftFloor() int64 {
+	value := os.Getenv("OVERDRAFT_FLOOR")
+	if value == "" {
+		return 0
+	}
+
+	floor, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid OVERDRAFT_FLOOR: %v", err)
+	}
+	return floor
+}
+
+// getAgingBuckets reads PENDING_AGING_BUCKETS, the upper bounds in days of
+// the pending aging buckets.
+func getAgingBuckets() []int {
+	value := os.Getenv("PENDING_AGING_BUCKETS")
+	if value == "" {
+		return service.DefaultAgingBuckets
+	}
+
+	bounds, err := service.ParseAgingBuckets(value)
+	if err != nil {
+		log.Fatalf("Invalid PENDING_AGING_BUCKETS: %v", err)
+	}
+	return bounds
+}
+
+// getPendingExpiryDays reads PENDING_EXPIRY_DAYS, the age in days after
+// which PENDING transactions are failed. Zero or unset disables expiry.
+func getPendingExpiryDays() int {
+	value := os.Getenv("PENDING_EXPIRY_DAYS")
+	if value == "" {
+		return 0
+	}
+
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		log.Fatalf("Invalid PENDING_EXPIRY_DAYS: %s", value)
+	}
+	return days
+}
+
+// getScheduler sets up background jobs. Pending expiry runs every
+// PENDING_EXPIRY_INTERVAL (default 1h) when PENDING_EXPIRY_DAYS is set, and
+// only logs what it would do when PENDING_EXPIRY_DRY_RUN is true.
+func getScheduler(transactionService *service.TransactionService) *scheduler.Scheduler {
+	days := transactionService.PendingExpiryDays
+	if days == 0 {
+		return scheduler.NewScheduler()
+	}
+
+	interval := time.Hour
+	if value := os.Getenv("PENDING_EXPIRY_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			log.Fatalf("Invalid PENDING_EXPIRY_INTERVAL: %s", value)
+		}
+		interval = parsed
+	}
+
+	dryRun := false
+	if value := os.Getenv("PENDING_EXPIRY_DRY_RUN"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Fatalf("Invalid PENDING_EXPIRY_DRY_RUN: %v", err)
+		}
+		dryRun = parsed
+	}
+
+	return scheduler.NewScheduler(scheduler.Job{
+		Name:     "pending-expiry",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			result, err := transactionService.ExpirePending(ctx, time.Now().UTC(), days, dryRun)
+			if dryRun {
+				for _, transaction := range result.Expired {
+					log.Printf("Pending expiry (dry run): would fail transaction %s dated %s", transaction.ID, transaction.TransactionDate.Format(time.RFC3339))
+				}
+			} else if len(result.Expired) > 0 || result.Skipped > 0 {
+				log.Printf("Pending expiry: failed %d transactions, skipped %d", len(result.Expired), result.Skipped)
+			}
+			return err
+		},
+	})
+}
+
+func gracefulShutdown(server *http.Server, jobs *scheduler.Scheduler) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 
@@ -70,6 +352,9 @@ func gracefulShutdown(server *http.Server) {
 	if err := server.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
+	if err := jobs.Stop(ctx); err != nil {
+		log.Printf("Background jobs did not stop in time: %v", err)
+	}
 
 	log.Println("Server stopped gracefully")
 }
@@ -3,3 +3,5 @@ module flip-test
 go 1.24.4
 
 require github.com/google/uuid v1.6.0
+
+require golang.org/x/text v0.30.0
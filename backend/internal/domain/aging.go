@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// AgingBucket groups pending transactions aged MinDays to MaxDays whole
+// days, both inclusive. A nil MaxDays leaves the bucket open-ended.
+type AgingBucket struct {
+	Label   string             `json:"label"`
+	MinDays int                `json:"min_days"`
+	MaxDays *int               `json:"max_days"`
+	Count   int                `json:"count"`
+	Amounts map[Currency]int64 `json:"amounts"`
+	Oldest  *Transaction       `json:"oldest"`
+}
+
+type AgingReport struct {
+	ReferenceTime time.Time     `json:"reference_time"`
+	Buckets       []AgingBucket `json:"buckets"`
+}
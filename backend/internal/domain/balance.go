@@ -0,0 +1,74 @@
+package domain
+
+import "time"
+
+type MissingRate struct {
+	Currency     Currency `json:"currency"`
+	Date         string   `json:"date"`
+	Transactions int      `json:"transactions"`
+}
+
+// ConsolidatedBalance is the balance of all currencies converted into one
+// reporting currency. Transactions listed in MissingRates are left out of it.
+type ConsolidatedBalance struct {
+	Currency     Currency      `json:"currency"`
+	Balance      int64         `json:"balance"`
+	MinorUnits   int           `json:"minor_units"`
+	Complete     bool          `json:"complete"`
+	MissingRates []MissingRate `json:"missing_rates"`
+}
+
+// BalanceTotals are the running sums of one currency and status.
+type BalanceTotals struct {
+	Credits      int64 `json:"credits"`
+	Debits       int64 `json:"debits"`
+	Net          int64 `json:"net"`
+	Transactions int   `json:"transactions"`
+}
+
+// Apply adds the transaction to the totals, or takes it out again when sign
+// is negative.
+func (b *BalanceTotals) Apply(t Transaction, sign int64) {
+	if t.Type == TransactionTypeCredit {
+		b.Credits += sign * t.Amount
+		b.Net += sign * t.Amount
+	} else {
+		b.Debits += sign * t.Amount
+		b.Net -= sign * t.Amount
+	}
+	b.Transactions += int(sign)
+}
+
+// BalanceSummary holds the totals of every currency per status.
+type BalanceSummary map[Currency]map[TransactionStatus]BalanceTotals
+
+// BalanceBreakdown shows how the balance of one currency is made up.
+// ProjectedBalance is NetBalance with every pending transaction settled.
+type BalanceBreakdown struct {
+	Currency          Currency                  `json:"currency"`
+	MinorUnits        int                       `json:"minor_units"`
+	SuccessfulCredits int64                     `json:"successful_credits"`
+	SuccessfulDebits  int64                     `json:"successful_debits"`
+	NetBalance        int64                     `json:"net_balance"`
+	PendingInflow     int64                     `json:"pending_inflow"`
+	PendingOutflow    int64                     `json:"pending_outflow"`
+	FailedCredits     int64                     `json:"failed_credits"`
+	FailedDebits      int64                     `json:"failed_debits"`
+	ProjectedBalance  int64                     `json:"projected_balance"`
+	Counts            map[TransactionStatus]int `json:"counts"`
+}
+
+// PeriodBalance is the movement of successful transactions of one currency
+// between From and To, both inclusive. A zero From means since the first
+// transaction and a zero To means up to now.
+type PeriodBalance struct {
+	Currency       Currency  `json:"currency"`
+	MinorUnits     int       `json:"minor_units"`
+	From           time.Time `json:"from,omitzero"`
+	To             time.Time `json:"to,omitzero"`
+	OpeningBalance int64     `json:"opening_balance"`
+	Credits        int64     `json:"credits"`
+	Debits         int64     `json:"debits"`
+	Movement       int64     `json:"movement"`
+	ClosingBalance int64     `json:"closing_balance"`
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+type BucketInterval string
+
+const (
+	BucketDay   BucketInterval = "day"
+	BucketWeek  BucketInterval = "week"
+	BucketMonth BucketInterval = "month"
+)
+
+func ParseBucketInterval(value string) (BucketInterval, bool) {
+	switch interval := BucketInterval(strings.ToLower(strings.TrimSpace(value))); interval {
+	case BucketDay, BucketWeek, BucketMonth:
+		return interval, true
+	default:
+		return "", false
+	}
+}
+
+// Truncate returns the start of the bucket containing t in t's location.
+// Weeks start on Monday.
+func (b BucketInterval) Truncate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	switch b {
+	case BucketWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+	case BucketMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// Next returns the start of the bucket after the one starting at start.
+// Calendar arithmetic keeps buckets aligned across DST changes.
+func (b BucketInterval) Next(start time.Time) time.Time {
+	switch b {
+	case BucketWeek:
+		return start.AddDate(0, 0, 7)
+	case BucketMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// BalanceBucket covers [Start, next bucket start). ClosingBalance is the
+// running balance at the end of the bucket, including everything before it.
+type BalanceBucket struct {
+	Start          time.Time `json:"start"`
+	Credits        int64     `json:"credits"`
+	Debits         int64     `json:"debits"`
+	Net            int64     `json:"net"`
+	ClosingBalance int64     `json:"closing_balance"`
+}
+
+type BalanceSeries struct {
+	Currency       Currency        `json:"currency"`
+	MinorUnits     int             `json:"minor_units"`
+	Interval       BucketInterval  `json:"interval"`
+	Timezone       string          `json:"timezone"`
+	OpeningBalance int64           `json:"opening_balance"`
+	Buckets        []BalanceBucket `json:"buckets"`
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeName applies Unicode NFKC normalization and case folding to a
+// counterparty name and collapses runs of whitespace into one space, so
+// every spelling of a counterparty is stored the same way.
+func NormalizeName(name string) string {
+	return strings.Join(strings.Fields(cases.Fold().String(norm.NFKC.String(name))), " ")
+}
+
+// CounterpartyKey identifies the counterparty of a name. Names with the same
+// key belong to the same counterparty, including names stored before they
+// were case folded.
+func CounterpartyKey(name string) string {
+	return NormalizeName(name)
+}
+
+// CounterpartySummary aggregates the transactions of one counterparty in one
+// currency. Name is the name used by its most recent transaction. Credits,
+// Debits and Net only include successful transactions; Counts and
+// LastActivity include every status.
+type CounterpartySummary struct {
+	Name         string                    `json:"name"`
+	Currency     Currency                  `json:"currency"`
+	Credits      int64                     `json:"credits"`
+	Debits       int64                     `json:"debits"`
+	Net          int64                     `json:"net"`
+	Transactions int                       `json:"transactions"`
+	Counts       map[TransactionStatus]int `json:"counts"`
+	LastActivity time.Time                 `json:"last_activity"`
+}
+
+// LedgerEntry is a transaction with the counterparty's running balance after
+// it. Only successful transactions move the balance.
+type LedgerEntry struct {
+	Transaction
+	RunningBalance int64 `json:"running_balance"`
+}
+
+type CounterpartyLedger struct {
+	Name       string        `json:"name"`
+	Currency   Currency      `json:"currency"`
+	MinorUnits int           `json:"minor_units"`
+	Balance    int64         `json:"balance"`
+	Entries    []LedgerEntry `json:"entries"`
+}
+
+// NameSuggestion proposes merging two names that are likely the same
+// counterparty into Suggested, the one with more transactions.
+type NameSuggestion struct {
+	Names      []string `json:"names"`
+	Suggested  string   `json:"suggested"`
+	Similarity float64  `json:"similarity"`
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "strings"
+
+type Currency string
+
+const DefaultCurrency Currency = "IDR"
+
+// currencyMinorUnits holds the number of decimal places each currency is
+// stored with, following ISO 4217.
+var currencyMinorUnits = map[Currency]int{
+	"IDR": 2,
+	"USD": 2,
+	"SGD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"AUD": 2,
+	"MYR": 2,
+	"JPY": 0,
+}
+
+// MinorUnits returns the number of decimal places used to store amounts of
+// the currency, and false when the currency is not supported.
+func (c Currency) MinorUnits() (int, bool) {
+	units, ok := currencyMinorUnits[c]
+	return units, ok
+}
+
+func ParseCurrency(value string) (Currency, bool) {
+	currency := Currency(strings.ToUpper(strings.TrimSpace(value)))
+	_, ok := currency.MinorUnits()
+	return currency, ok
+}
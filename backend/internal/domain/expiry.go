@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// ExpiryResult reports a run that fails PENDING transactions dated before
+// Cutoff. In a dry run Expired lists what would have been failed. Skipped
+// counts transactions that changed or disappeared during the run.
+type ExpiryResult struct {
+	DryRun  bool          `json:"dry_run"`
+	Cutoff  time.Time     `json:"cutoff"`
+	Expired []Transaction `json:"expired"`
+	Skipped int           `json:"skipped"`
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IssueState string
+
+const (
+	IssueStateOpen          IssueState = "open"
+	IssueStateInvestigating IssueState = "investigating"
+	IssueStateResolved      IssueState = "resolved"
+	IssueStateIgnored       IssueState = "ignored"
+)
+
+func ParseIssueState(value string) (IssueState, bool) {
+	switch state := IssueState(strings.ToLower(strings.TrimSpace(value))); state {
+	case IssueStateOpen, IssueStateInvestigating, IssueStateResolved, IssueStateIgnored:
+		return state, true
+	default:
+		return "", false
+	}
+}
+
+// IsClosed reports whether the issue needs no further follow-up.
+func (s IssueState) IsClosed() bool {
+	return s == IssueStateResolved || s == IssueStateIgnored
+}
+
+type IssueNote struct {
+	Author    string    `json:"author"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Issue is the follow-up state of a FAILED or PENDING transaction. Every such
+// transaction has an issue; it is only stored once it is first updated, and
+// until then it is open, unassigned and has a zero CreatedAt.
+type Issue struct {
+	TransactionID uuid.UUID   `json:"transaction_id"`
+	State         IssueState  `json:"state"`
+	Assignee      string      `json:"assignee,omitempty"`
+	Notes         []IssueNote `json:"notes"`
+	CreatedAt     time.Time   `json:"created_at,omitzero"`
+	UpdatedAt     time.Time   `json:"updated_at,omitzero"`
+	ClosedAt      *time.Time  `json:"closed_at,omitempty"`
+}
+
+type TransactionIssue struct {
+	Issue
+	Transaction Transaction `json:"transaction"`
+}
+
+// IssueFilter selects issues. Zero-valued fields do not filter.
+type IssueFilter struct {
+	States   []IssueState
+	Statuses []TransactionStatus
+	// Assignee matches ignoring case. Unassigned selects issues without one.
+	Assignee   string
+	Unassigned bool
+}
+
+func (f IssueFilter) Matches(issue TransactionIssue) bool {
+	if len(f.States) > 0 && !contains(f.States, issue.State) {
+		return false
+	}
+	if len(f.Statuses) > 0 && !contains(f.Statuses, issue.Transaction.Status) {
+		return false
+	}
+	if f.Assignee != "" && !strings.EqualFold(f.Assignee, issue.Assignee) {
+		return false
+	}
+	if f.Unassigned && issue.Assignee != "" {
+		return false
+	}
+	return true
+}
+
+// IssueUpdate changes the fields that are set. Note, when not empty, is
+// appended to the issue notes.
+type IssueUpdate struct {
+	State    *IssueState
+	Assignee *string
+	Note     string
+}
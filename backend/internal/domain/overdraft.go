@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// Overdraft is a period in which the running balance of successful
+// transactions was below Floor. Cause is the transaction that took the
+// balance below it and Recovery the one that brought it back; Recovery and
+// EndedAt are nil while the overdraft is ongoing.
+type Overdraft struct {
+	Currency      Currency     `json:"currency"`
+	Floor         int64        `json:"floor"`
+	StartedAt     time.Time    `json:"started_at"`
+	EndedAt       *time.Time   `json:"ended_at"`
+	Cause         Transaction  `json:"cause"`
+	Recovery      *Transaction `json:"recovery"`
+	LowestBalance int64        `json:"lowest_balance"`
+	LowestAt      time.Time    `json:"lowest_at"`
+	// Depth is how far below Floor the balance went at its lowest.
+	Depth int64 `json:"depth"`
+	// DurationSeconds runs up to now for an ongoing overdraft.
+	DurationSeconds int64 `json:"duration_seconds"`
+}
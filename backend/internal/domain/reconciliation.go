@@ -0,0 +1,53 @@
+package domain
+
+import "time"
+
+type MatchMethod string
+
+const (
+	// MatchByID matches a statement row to the stored transaction with the
+	// same ID, derived from its reference or its content.
+	MatchByID MatchMethod = "id"
+	// MatchByDetails matches on type, currency and counterparty with dates
+	// within the reconciliation tolerance.
+	MatchByDetails MatchMethod = "details"
+)
+
+// StatementRow is a transaction read from a statement together with its
+// line in the file.
+type StatementRow struct {
+	Line        int         `json:"line"`
+	Transaction Transaction `json:"transaction"`
+}
+
+// ReconciliationMatch pairs a statement row with the stored transaction it
+// was matched to. Differences names the fields that disagree, "status"
+// and/or "amount"; it is empty for a clean match.
+type ReconciliationMatch struct {
+	Line        int         `json:"line"`
+	Statement   Transaction `json:"statement"`
+	Stored      Transaction `json:"stored"`
+	MatchedBy   MatchMethod `json:"matched_by"`
+	Differences []string    `json:"differences,omitempty"`
+}
+
+type ReconciliationSummary struct {
+	Matched       int `json:"matched"`
+	Mismatched    int `json:"mismatched"`
+	StatementOnly int `json:"statement_only"`
+	StoredOnly    int `json:"stored_only"`
+}
+
+// ReconciliationReport compares a statement with the stored transactions.
+// StoredOnly lists unmatched stored transactions dated within [From, To],
+// the period the statement covers.
+type ReconciliationReport struct {
+	From          time.Time             `json:"from,omitzero"`
+	To            time.Time             `json:"to,omitzero"`
+	Tolerance     string                `json:"tolerance"`
+	Summary       ReconciliationSummary `json:"summary"`
+	Matched       []ReconciliationMatch `json:"matched"`
+	Mismatched    []ReconciliationMatch `json:"mismatched"`
+	StatementOnly []StatementRow        `json:"statement_only"`
+	StoredOnly    []Transaction         `json:"stored_only"`
+}
@@ -1,6 +1,10 @@
 package domain
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,12 +24,162 @@ const (
 	TransactionTypeCredit TransactionType = "CREDIT"
 )
 
+// TransactionStatuses lists every status in reporting order.
+var TransactionStatuses = []TransactionStatus{TransactionStatusSuccess, TransactionStatusPending, TransactionStatusFailed}
+
+func (s TransactionStatus) IsValid() bool {
+	return s == TransactionStatusSuccess || s == TransactionStatusPending || s == TransactionStatusFailed
+}
+
+// CanTransitionTo reports whether a transaction may move from s to next.
+// Only PENDING transactions can change; SUCCESS and FAILED are terminal.
+func (s TransactionStatus) CanTransitionTo(next TransactionStatus) bool {
+	return s == TransactionStatusPending && (next == TransactionStatusSuccess || next == TransactionStatusFailed)
+}
+
+// StatusChange records one status transition of a transaction.
+type StatusChange struct {
+	From      TransactionStatus `json:"from"`
+	To        TransactionStatus `json:"to"`
+	Reason    string            `json:"reason"`
+	ChangedBy string            `json:"changed_by"`
+	ChangedAt time.Time         `json:"changed_at"`
+}
+
+type SaveOutcome string
+
+const (
+	SaveOutcomeInserted  SaveOutcome = "INSERTED"
+	SaveOutcomeDuplicate SaveOutcome = "DUPLICATE"
+	SaveOutcomeConflict  SaveOutcome = "CONFLICT"
+)
+
+// SaveResult reports what happened to each transaction passed to a save, in
+// input order.
+type SaveResult struct {
+	Outcomes   []SaveOutcome `json:"-"`
+	Inserted   int           `json:"inserted"`
+	Duplicates int           `json:"duplicates"`
+	Conflicts  int           `json:"conflicts"`
+}
+
+func (r *SaveResult) Add(outcome SaveOutcome) {
+	r.Outcomes = append(r.Outcomes, outcome)
+	switch outcome {
+	case SaveOutcomeInserted:
+		r.Inserted++
+	case SaveOutcomeDuplicate:
+		r.Duplicates++
+	case SaveOutcomeConflict:
+		r.Conflicts++
+	}
+}
+
+// transactionNamespace seeds the name-based UUIDs of imported transactions.
+var transactionNamespace = uuid.MustParse("6f1c9f4e-3b0a-4c55-9d43-2f7d8e1a5b60")
+
 type Transaction struct {
 	ID              uuid.UUID         `json:"id"`
+	Reference       string            `json:"reference,omitempty"`
 	Name            string            `json:"name"`
 	Type            TransactionType   `json:"type"`
 	Amount          int64             `json:"amount"`
+	Currency        Currency          `json:"currency"`
 	Status          TransactionStatus `json:"status"`
 	Description     string            `json:"description"`
 	TransactionDate time.Time         `json:"transaction_date"`
+	UploadID        uuid.UUID         `json:"upload_id"`
+	StatusHistory   []StatusChange    `json:"status_history,omitempty"`
+	// ReversalOf links a reversal or refund to the transaction it undoes and
+	// ReversedBy links the original back to it. Both keep their own status
+	// and opposite types, so the pair nets to zero from the reversal date on
+	// while balances before that date stay as they were.
+	ReversalOf uuid.UUID `json:"reversal_of,omitzero"`
+	ReversedBy uuid.UUID `json:"reversed_by,omitzero"`
+	// AliasedFrom is the name in the imported file when a name alias
+	// replaced it.
+	AliasedFrom string `json:"aliased_from,omitempty"`
+}
+
+func (t Transaction) IsReversal() bool {
+	return t.ReversalOf != uuid.Nil
+}
+
+// ReversalPair is an original transaction together with its reversal.
+type ReversalPair struct {
+	Original Transaction `json:"original"`
+	Reversal Transaction `json:"reversal"`
+}
+
+// EffectiveCurrency returns the transaction currency, falling back to
+// DefaultCurrency for transactions stored before currencies were tracked.
+func (t Transaction) EffectiveCurrency() Currency {
+	if t.Currency == "" {
+		return DefaultCurrency
+	}
+	return t.Currency
+}
+
+// NewTransactionID derives a deterministic ID so re-importing the same row
+// yields the same transaction. The external reference is used when present,
+// otherwise a hash of the timestamp, imported name, type, amount, currency
+// and description.
+func NewTransactionID(t Transaction) uuid.UUID {
+	if t.Reference != "" {
+		return ReferenceTransactionID(t.Reference)
+	}
+
+	return uuid.NewSHA1(transactionNamespace, []byte("content:"+joinFields(
+		strconv.FormatInt(t.TransactionDate.UnixNano(), 10),
+		t.ImportedName(),
+		string(t.Type),
+		strconv.FormatInt(t.Amount, 10),
+		string(t.EffectiveCurrency()),
+		t.Description,
+	)))
+}
+
+func ReferenceTransactionID(reference string) uuid.UUID {
+	return uuid.NewSHA1(transactionNamespace, []byte("reference:"+reference))
+}
+
+// ImportedStatus is the status the transaction had when it was imported,
+// before any change in StatusHistory.
+func (t Transaction) ImportedStatus() TransactionStatus {
+	if len(t.StatusHistory) > 0 {
+		return t.StatusHistory[0].From
+	}
+	return t.Status
+}
+
+// ImportedName is the name as it appeared in the imported file, before any
+// name alias.
+func (t Transaction) ImportedName() string {
+	if t.AliasedFrom != "" {
+		return t.AliasedFrom
+	}
+	return t.Name
+}
+
+// Fingerprint hashes every imported field, so two rows with the same ID but
+// different content can be told apart. It uses the imported status and name,
+// so re-importing a row that was settled or aliased later is still a
+// duplicate.
+func (t Transaction) Fingerprint() string {
+	sum := sha256.Sum256([]byte(joinFields(
+		t.Reference,
+		strconv.FormatInt(t.TransactionDate.UnixNano(), 10),
+		t.ImportedName(),
+		string(t.Type),
+		strconv.FormatInt(t.Amount, 10),
+		string(t.EffectiveCurrency()),
+		string(t.ImportedStatus()),
+		t.Description,
+		t.ReversalOf.String(),
+	)))
+	return hex.EncodeToString(sum[:])
+}
+
+func joinFields(fields ...string) string {
+	return strings.Join(fields, "\x1f")
 }
@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"bytes"
+	"cmp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TransactionSortField string
+
+const (
+	SortByTransactionDate TransactionSortField = "transaction_date"
+	SortByAmount          TransactionSortField = "amount"
+	SortByName            TransactionSortField = "name"
+	SortByType            TransactionSortField = "type"
+	SortByStatus          TransactionSortField = "status"
+	SortByCurrency        TransactionSortField = "currency"
+	SortByDescription     TransactionSortField = "description"
+	SortByReference       TransactionSortField = "reference"
+	SortByID              TransactionSortField = "id"
+)
+
+func ParseTransactionSortField(value string) (TransactionSortField, bool) {
+	switch field := TransactionSortField(value); field {
+	case SortByTransactionDate, SortByAmount, SortByName, SortByType, SortByStatus,
+		SortByCurrency, SortByDescription, SortByReference, SortByID:
+		return field, true
+	default:
+		return "", false
+	}
+}
+
+// TransactionFilter selects transactions. Zero-valued fields do not filter.
+type TransactionFilter struct {
+	Statuses []TransactionStatus
+	Types    []TransactionType
+	// Name matches when it is contained in the transaction name, ignoring case.
+	Name      string
+	Currency  Currency
+	MinAmount *int64
+	MaxAmount *int64
+	// From and To bound TransactionDate, both inclusive.
+	From     time.Time
+	To       time.Time
+	UploadID uuid.UUID
+}
+
+func (f TransactionFilter) Matches(t Transaction) bool {
+	if len(f.Statuses) > 0 && !contains(f.Statuses, t.Status) {
+		return false
+	}
+	if len(f.Types) > 0 && !contains(f.Types, t.Type) {
+		return false
+	}
+	if f.Name != "" && !strings.Contains(strings.ToLower(t.Name), strings.ToLower(f.Name)) {
+		return false
+	}
+	if f.Currency != "" && t.EffectiveCurrency() != f.Currency {
+		return false
+	}
+	if f.MinAmount != nil && t.Amount < *f.MinAmount {
+		return false
+	}
+	if f.MaxAmount != nil && t.Amount > *f.MaxAmount {
+		return false
+	}
+	if !f.From.IsZero() && t.TransactionDate.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && t.TransactionDate.After(f.To) {
+		return false
+	}
+	if f.UploadID != uuid.Nil && t.UploadID != f.UploadID {
+		return false
+	}
+	return true
+}
+
+func contains[T comparable](values []T, value T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type TransactionQuery struct {
+	Filter     TransactionFilter
+	SortBy     TransactionSortField
+	Descending bool
+	// After is the last transaction of the previous page. Only its ID and
+	// the SortBy field are used.
+	After *Transaction
+	Limit int
+}
+
+// Before reports whether a comes before b in the query order. Ties on the
+// sort field are broken by ID so the order is total and pages never overlap.
+func (q TransactionQuery) Before(a, b Transaction) bool {
+	result := CompareTransactions(a, b, q.SortBy)
+	if result == 0 {
+		// Byte order matches the order of the hex strings without allocating.
+		result = bytes.Compare(a.ID[:], b.ID[:])
+	}
+	if q.Descending {
+		return result > 0
+	}
+	return result < 0
+}
+
+func CompareTransactions(a, b Transaction, field TransactionSortField) int {
+	switch field {
+	case SortByAmount:
+		return cmp.Compare(a.Amount, b.Amount)
+	case SortByName:
+		return strings.Compare(a.Name, b.Name)
+	case SortByType:
+		return strings.Compare(string(a.Type), string(b.Type))
+	case SortByStatus:
+		return strings.Compare(string(a.Status), string(b.Status))
+	case SortByCurrency:
+		return strings.Compare(string(a.EffectiveCurrency()), string(b.EffectiveCurrency()))
+	case SortByDescription:
+		return strings.Compare(a.Description, b.Description)
+	case SortByReference:
+		return strings.Compare(a.Reference, b.Reference)
+	case SortByID:
+		return 0
+	default:
+		return a.TransactionDate.Compare(b.TransactionDate)
+	}
+}
+
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
+}
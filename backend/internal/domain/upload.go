@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UploadStatus string
+
+const (
+	// UploadStatusImporting is recorded before any transaction is saved, so
+	// an import that stops halfway still names its transactions' upload.
+	UploadStatusImporting UploadStatus = "IMPORTING"
+	UploadStatusCompleted UploadStatus = "COMPLETED"
+	UploadStatusFailed    UploadStatus = "FAILED"
+)
+
+type Upload struct {
+	ID         uuid.UUID    `json:"id"`
+	Filename   string       `json:"filename"`
+	Size       int64        `json:"size"`
+	Checksum   string       `json:"checksum"`
+	Status     UploadStatus `json:"status"`
+	TotalRows  int          `json:"total_rows"`
+	Inserted   int          `json:"inserted"`
+	Duplicates int          `json:"duplicates"`
+	Conflicts  int          `json:"conflicts"`
+	Rejected   int          `json:"rejected"`
+	UploadedBy string       `json:"uploaded_by"`
+	UploadedAt time.Time    `json:"uploaded_at"`
+	// Rollback is set once every transaction of the upload has been removed.
+	Rollback *UploadRollback `json:"rollback,omitempty"`
+}
+
+type UploadRollback struct {
+	RolledBackBy        string    `json:"rolled_back_by"`
+	RolledBackAt        time.Time `json:"rolled_back_at"`
+	Reason              string    `json:"reason"`
+	TransactionsRemoved int       `json:"transactions_removed"`
+}
@@ -0,0 +1,266 @@
+package fx
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"flip-test/internal/domain"
+)
+
+const dateLayout = "2006-01-02"
+
+// DefaultMaxAgeDays is how many days a rate carries forward when no newer
+// one is published, enough to cover weekends and most holiday breaks.
+const DefaultMaxAgeDays = 7
+
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+type Rate struct {
+	Date  time.Time
+	From  domain.Currency
+	To    domain.Currency
+	Value *big.Rat
+}
+
+type pair struct {
+	from domain.Currency
+	to   domain.Currency
+}
+
+// RateTable holds dated exchange rates. A rate applies from its date until the
+// next dated rate for the same pair, so weekends and holidays reuse the last
+// published rate. With MaxAgeDays set, a rate older than that many days no
+// longer applies and the day has no rate; zero carries rates forward
+// indefinitely.
+type RateTable struct {
+	MaxAgeDays int
+	rates      map[pair][]Rate
+}
+
+func NewRateTable(rates []Rate) *RateTable {
+	rt := &RateTable{rates: make(map[pair][]Rate)}
+	for _, rate := range rates {
+		rate.Date = truncateToDay(rate.Date)
+		key := pair{from: rate.From, to: rate.To}
+		rt.rates[key] = append(rt.rates[key], rate)
+	}
+
+	for _, rates := range rt.rates {
+		sort.Slice(rates, func(i, j int) bool {
+			return rates[i].Date.Before(rates[j].Date)
+		})
+	}
+
+	return rt
+}
+
+// LoadRateTable reads rates from a .csv file with the header
+// "date,from,to,rate" or from a .json array of objects with the same keys.
+func LoadRateTable(path string) (*RateTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rate file: %w", err)
+	}
+	defer file.Close()
+
+	var rates []Rate
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		rates, err = readCSVRates(file)
+	case ".json":
+		rates, err = readJSONRates(file)
+	default:
+		return nil, fmt.Errorf("unsupported rate file '%s': must be .csv or .json", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRateTable(rates), nil
+}
+
+func readCSVRates(r io.Reader) ([]Rate, error) {
+	reader := csv.NewReader(r)
+
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rate file header: %w", err)
+	}
+	expected := []string{"date", "from", "to", "rate"}
+	if len(headers) != len(expected) {
+		return nil, fmt.Errorf("invalid rate file header: expected %s", strings.Join(expected, ","))
+	}
+	for i, header := range headers {
+		if strings.ToLower(strings.TrimSpace(header)) != expected[i] {
+			return nil, fmt.Errorf("invalid rate file header: expected %s", strings.Join(expected, ","))
+		}
+	}
+
+	var rates []Rate
+	lineNum := 1
+	for {
+		lineNum++
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: failed to read rate: %w", lineNum, err)
+		}
+
+		rate, err := parseRate(record[0], record[1], record[2], record[3])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+func readJSONRates(r io.Reader) ([]Rate, error) {
+	var records []struct {
+		Date string      `json:"date"`
+		From string      `json:"from"`
+		To   string      `json:"to"`
+		Rate json.Number `json:"rate"`
+	}
+
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&records); err != nil {
+		return nil, fmt.Errorf("failed to decode rate file: %w", err)
+	}
+
+	rates := make([]Rate, 0, len(records))
+	for i, record := range records {
+		rate, err := parseRate(record.Date, record.From, record.To, record.Rate.String())
+		if err != nil {
+			return nil, fmt.Errorf("rate %d: %w", i+1, err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+func parseRate(date, from, to, value string) (Rate, error) {
+	day, err := time.Parse(dateLayout, strings.TrimSpace(date))
+	if err != nil {
+		return Rate{}, fmt.Errorf("invalid date '%s': %w", date, err)
+	}
+
+	fromCurrency, ok := domain.ParseCurrency(from)
+	if !ok {
+		return Rate{}, fmt.Errorf("invalid currency '%s'", from)
+	}
+	toCurrency, ok := domain.ParseCurrency(to)
+	if !ok {
+		return Rate{}, fmt.Errorf("invalid currency '%s'", to)
+	}
+
+	rate, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || rate.Sign() <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate '%s': must be a positive decimal", value)
+	}
+
+	return Rate{Date: day, From: fromCurrency, To: toCurrency, Value: rate}, nil
+}
+
+// Rate returns how many units of to one unit of from was worth on the given
+// day, using the inverse pair when only that one is published.
+func (rt *RateTable) Rate(from, to domain.Currency, on time.Time) (*big.Rat, bool) {
+	if from == to {
+		return big.NewRat(1, 1), true
+	}
+
+	if rate, ok := rt.lookup(pair{from: from, to: to}, on); ok {
+		return rate, true
+	}
+	if rate, ok := rt.lookup(pair{from: to, to: from}, on); ok {
+		return new(big.Rat).Inv(rate), true
+	}
+
+	return nil, false
+}
+
+func (rt *RateTable) lookup(key pair, on time.Time) (*big.Rat, bool) {
+	rates := rt.rates[key]
+	day := truncateToDay(on)
+
+	// Index of the first rate dated after the day; the one before it applies.
+	i := sort.Search(len(rates), func(i int) bool {
+		return rates[i].Date.After(day)
+	})
+	if i == 0 {
+		return nil, false
+	}
+	if rt.MaxAgeDays > 0 && rates[i-1].Date.AddDate(0, 0, rt.MaxAgeDays).Before(day) {
+		return nil, false
+	}
+
+	return rates[i-1].Value, true
+}
+
+// Convert converts an amount in minor units of from into minor units of to
+// at the rate of the given day, rounding half away from zero.
+func (rt *RateTable) Convert(amount int64, from, to domain.Currency, on time.Time) (int64, bool, error) {
+	fromUnits, ok := from.MinorUnits()
+	if !ok {
+		return 0, false, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, from)
+	}
+	toUnits, ok := to.MinorUnits()
+	if !ok {
+		return 0, false, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, to)
+	}
+
+	rate, ok := rt.Rate(from, to, on)
+	if !ok {
+		return 0, false, nil
+	}
+
+	converted := new(big.Rat).SetInt64(amount)
+	converted.Mul(converted, rate)
+	converted.Mul(converted, new(big.Rat).SetFrac(pow10(toUnits), pow10(fromUnits)))
+
+	result := roundHalfAwayFromZero(converted)
+	if !result.IsInt64() {
+		return 0, false, fmt.Errorf("converted amount overflows: %s", result)
+	}
+
+	return result.Int64(), true, nil
+}
+
+func roundHalfAwayFromZero(value *big.Rat) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+
+	doubled := new(big.Int).Abs(remainder)
+	doubled.Lsh(doubled, 1)
+	if doubled.Cmp(value.Denom()) >= 0 {
+		if value.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+
+	return quotient
+}
+
+func pow10(exponent int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
+
+func truncateToDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,126 @@
+package fx
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRateTable_UsesLatestRateOnOrBeforeDate(t *testing.T) {
+	rt := NewRateTable([]Rate{
+		{Date: day(2024, 1, 2), From: "USD", To: "IDR", Value: big.NewRat(15600, 1)},
+		{Date: day(2024, 1, 1), From: "USD", To: "IDR", Value: big.NewRat(15500, 1)},
+	})
+
+	tests := []struct {
+		on       time.Time
+		expected int64
+		ok       bool
+	}{
+		{day(2023, 12, 31), 0, false},
+		{day(2024, 1, 1).Add(23 * time.Hour), 1550000, true},
+		{day(2024, 1, 2), 1560000, true},
+		{day(2024, 1, 6), 1560000, true},
+	}
+
+	for _, tt := range tests {
+		converted, ok, err := rt.Convert(100, "USD", "IDR", tt.on)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if ok != tt.ok || converted != tt.expected {
+			t.Errorf("On %s expected (%d, %v), got (%d, %v)", tt.on.Format(dateLayout), tt.expected, tt.ok, converted, ok)
+		}
+	}
+}
+
+func TestRateTable_MaxAgeDays(t *testing.T) {
+	rt := NewRateTable([]Rate{
+		{Date: day(2024, 1, 5), From: "USD", To: "IDR", Value: big.NewRat(15600, 1)},
+	})
+	rt.MaxAgeDays = 3
+
+	if _, ok := rt.Rate("USD", "IDR", day(2024, 1, 8).Add(23*time.Hour)); !ok {
+		t.Error("Expected the rate to apply on the last day within its age")
+	}
+	if _, ok := rt.Rate("USD", "IDR", day(2024, 1, 9)); ok {
+		t.Error("Expected no rate once it is older than MaxAgeDays")
+	}
+	if _, ok := rt.Rate("IDR", "USD", day(2024, 1, 9)); ok {
+		t.Error("Expected the inverse rate to expire as well")
+	}
+}
+
+func TestRateTable_InverseAndRounding(t *testing.T) {
+	rt := NewRateTable([]Rate{
+		{Date: day(2024, 1, 1), From: "USD", To: "IDR", Value: big.NewRat(15000, 1)},
+	})
+
+	// 10,001 IDR / 15,000 = 0.66673 USD, rounded to 67 cents.
+	converted, ok, err := rt.Convert(1000100, "IDR", "USD", day(2024, 1, 1))
+	if err != nil || !ok {
+		t.Fatalf("Expected conversion, got ok=%v err=%v", ok, err)
+	}
+	if converted != 67 {
+		t.Errorf("Expected 67 cents, got %d", converted)
+	}
+
+	converted, ok, _ = rt.Convert(-750000, "IDR", "USD", day(2024, 1, 1))
+	if !ok || converted != -50 {
+		t.Errorf("Expected -50 cents, got %d", converted)
+	}
+
+	converted, ok, _ = rt.Convert(1234, "IDR", "IDR", day(2000, 1, 1))
+	if !ok || converted != 1234 {
+		t.Errorf("Expected identity conversion, got %d", converted)
+	}
+}
+
+func TestLoadRateTable_CSVAndJSON(t *testing.T) {
+	dir := t.TempDir()
+
+	csvPath := filepath.Join(dir, "rates.csv")
+	csvData := "date,from,to,rate\n2024-01-01,USD,IDR,15500.5\n2024-01-01,sgd,IDR,11600\n"
+	if err := os.WriteFile(csvPath, []byte(csvData), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	jsonPath := filepath.Join(dir, "rates.json")
+	jsonData := `[{"date":"2024-01-01","from":"USD","to":"IDR","rate":15500.5},{"date":"2024-01-01","from":"SGD","to":"IDR","rate":"11600"}]`
+	if err := os.WriteFile(jsonPath, []byte(jsonData), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{csvPath, jsonPath} {
+		rt, err := LoadRateTable(path)
+		if err != nil {
+			t.Fatalf("Expected no error loading %s, got: %v", path, err)
+		}
+
+		converted, ok, err := rt.Convert(200, "USD", "IDR", day(2024, 1, 1))
+		if err != nil || !ok || converted != 3100100 {
+			t.Errorf("%s: expected 3100100, got %d (ok=%v, err=%v)", path, converted, ok, err)
+		}
+
+		if _, ok := rt.Rate("SGD", "IDR", day(2024, 1, 1)); !ok {
+			t.Errorf("%s: expected SGD rate to be loaded", path)
+		}
+	}
+}
+
+func TestLoadRateTable_InvalidRate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	if err := os.WriteFile(path, []byte("date,from,to,rate\n2024-01-01,USD,IDR,-1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadRateTable(path); err == nil {
+		t.Error("Expected error for negative rate, got none")
+	}
+}
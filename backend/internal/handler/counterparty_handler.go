@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"flip-test/internal/domain"
+	"flip-test/internal/service"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+type CounterpartyHandler struct {
+	CounterpartyService *service.CounterpartyService
+}
+
+func NewCounterpartyHandler(cs *service.CounterpartyService) *CounterpartyHandler {
+	return &CounterpartyHandler{
+		CounterpartyService: cs,
+	}
+}
+
+// GetCounterparties lists counterparty summaries of every currency, or of
+// one when the currency parameter is given.
+func (ch *CounterpartyHandler) GetCounterparties(w http.ResponseWriter, req *http.Request) {
+	var currency domain.Currency
+	if value := req.URL.Query().Get("currency"); value != "" {
+		var ok bool
+		if currency, ok = domain.ParseCurrency(value); !ok {
+			WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", fmt.Sprintf("unsupported currency '%s'", value), nil)
+			return
+		}
+	}
+
+	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", ch.CounterpartyService.GetCounterparties(currency))
+}
+
+func (ch *CounterpartyHandler) GetLedger(w http.ResponseWriter, req *http.Request) {
+	currency, err := currencyParam(req)
+	if err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+
+	ledger, err := ch.CounterpartyService.GetLedger(req.PathValue("name"), currency)
+	if errors.Is(err, service.ErrCounterpartyNotFound) {
+		WriteJSON(w, http.StatusNotFound, "NOT_FOUND", err.Error(), nil)
+		return
+	}
+	if err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", ledger)
+}
+
+// SuggestMerges lists pairs of counterparty names that are probably the same
+// counterparty. threshold, between 0 and 1, defaults to 0.8.
+func (ch *CounterpartyHandler) SuggestMerges(w http.ResponseWriter, req *http.Request) {
+	threshold := 0.8
+	if value := req.URL.Query().Get("threshold"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 || parsed > 1 {
+			WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", fmt.Sprintf("invalid threshold '%s': must be greater than 0 and at most 1", value), nil)
+			return
+		}
+		threshold = parsed
+	}
+
+	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", ch.CounterpartyService.SuggestMerges(threshold))
+}
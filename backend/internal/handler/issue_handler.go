@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"flip-test/internal/domain"
+	"flip-test/internal/service"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// UpdateIssueRequest changes only the fields present in the body.
+type UpdateIssueRequest struct {
+	State    *domain.IssueState `json:"state"`
+	Assignee *string            `json:"assignee"`
+	Note     string             `json:"note"`
+}
+
+type IssueHandler struct {
+	IssueService *service.IssueService
+}
+
+func NewIssueHandler(is *service.IssueService) *IssueHandler {
+	return &IssueHandler{
+		IssueService: is,
+	}
+}
+
+// GetIssues lists issues filtered by state, status (the transaction status),
+// assignee and unassigned=true.
+func (ih *IssueHandler) GetIssues(w http.ResponseWriter, req *http.Request) {
+	filter, err := parseIssueFilter(req)
+	if err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", ih.IssueService.GetIssues(filter))
+}
+
+func (ih *IssueHandler) GetIssue(w http.ResponseWriter, req *http.Request) {
+	id, err := uuid.Parse(req.PathValue("id"))
+	if err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid transaction ID", nil)
+		return
+	}
+
+	issue, err := ih.IssueService.GetIssue(id)
+	if err != nil {
+		writeIssueError(w, id, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", issue)
+}
+
+func (ih *IssueHandler) UpdateIssue(w http.ResponseWriter, req *http.Request) {
+	id, err := uuid.Parse(req.PathValue("id"))
+	if err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid transaction ID", nil)
+		return
+	}
+
+	var body UpdateIssueRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body", nil)
+		return
+	}
+
+	issue, err := ih.IssueService.UpdateIssue(id, domain.IssueUpdate{
+		State:    body.State,
+		Assignee: body.Assignee,
+		Note:     body.Note,
+	}, requestActor(req))
+	if err != nil {
+		writeIssueError(w, id, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, "SUCCESS", "Issue updated", issue)
+}
+
+func writeIssueError(w http.ResponseWriter, id uuid.UUID, err error) {
+	switch {
+	case errors.Is(err, service.ErrTransactionNotFound):
+		WriteJSON(w, http.StatusNotFound, "NOT_FOUND", err.Error(), nil)
+	case errors.Is(err, service.ErrNoIssue):
+		WriteJSON(w, http.StatusConflict, "CONFLICT", err.Error(), nil)
+	case errors.Is(err, service.ErrInvalidIssueState), errors.Is(err, service.ErrEmptyIssueUpdate):
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+	default:
+		log.Printf("Failed to handle issue of transaction %s: %v", id, err)
+		WriteJSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to handle issue", nil)
+	}
+}
+
+func parseIssueFilter(req *http.Request) (domain.IssueFilter, error) {
+	var filter domain.IssueFilter
+
+	for _, value := range queryValues(req, "state") {
+		state, ok := domain.ParseIssueState(value)
+		if !ok {
+			return filter, fmt.Errorf("invalid state '%s'", value)
+		}
+		filter.States = append(filter.States, state)
+	}
+
+	for _, value := range queryValues(req, "status") {
+		status := domain.TransactionStatus(strings.ToUpper(value))
+		if status != domain.TransactionStatusFailed && status != domain.TransactionStatusPending {
+			return filter, fmt.Errorf("invalid status '%s'. Must be 'FAILED' or 'PENDING'", value)
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+
+	filter.Assignee = req.URL.Query().Get("assignee")
+
+	if value := req.URL.Query().Get("unassigned"); value != "" {
+		unassigned, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid unassigned '%s': must be true or false", value)
+		}
+		filter.Unassigned = unassigned
+	}
+
+	return filter, nil
+}
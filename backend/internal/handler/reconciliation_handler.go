@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"flip-test/internal/domain"
+	"flip-test/internal/parser"
+	"flip-test/internal/service"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ReconcileResponse is the reconciliation report together with the
+// statement rows rejected in lenient mode, which take no part in matching.
+type ReconcileResponse struct {
+	domain.ReconciliationReport
+	Rejected int               `json:"rejected"`
+	Errors   []parser.RowError `json:"errors"`
+}
+
+type ReconciliationHandler struct {
+	ReconciliationService *service.ReconciliationService
+	ParseOptions          parser.Options
+}
+
+func NewReconciliationHandler(rs *service.ReconciliationService, parseOptions parser.Options) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		ReconciliationService: rs,
+		ParseOptions:          parseOptions,
+	}
+}
+
+// Reconcile matches an uploaded statement, in the upload CSV format, against
+// the stored transactions without saving anything. The tolerance form value
+// is a duration such as "48h" and defaults to
+// service.DefaultReconcileTolerance.
+func (rh *ReconciliationHandler) Reconcile(w http.ResponseWriter, req *http.Request) {
+	result, header, ok := parseCSVForm(w, req, rh.ParseOptions, nil)
+	if !ok {
+		return
+	}
+
+	tolerance := service.DefaultReconcileTolerance
+	if value := req.FormValue("tolerance"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", fmt.Sprintf("invalid tolerance '%s': must be a duration such as '24h'", value), nil)
+			return
+		}
+		tolerance = parsed
+	}
+
+	rows := make([]domain.StatementRow, len(result.Transactions))
+	for i, transaction := range result.Transactions {
+		rows[i] = domain.StatementRow{Line: result.Lines[i], Transaction: transaction}
+	}
+
+	report, err := rh.ReconciliationService.Reconcile(rows, tolerance)
+	if errors.Is(err, service.ErrInvalidTolerance) {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to reconcile statement: %v", err)
+		WriteJSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to reconcile statement", nil)
+		return
+	}
+
+	log.Printf("Reconciled %s: %d matched, %d mismatched, %d statement only, %d stored only",
+		header.Filename, report.Summary.Matched, report.Summary.Mismatched, report.Summary.StatementOnly, report.Summary.StoredOnly)
+
+	response := ReconcileResponse{
+		ReconciliationReport: report,
+		Rejected:             len(result.Errors),
+		Errors:               result.Errors,
+	}
+	if response.Errors == nil {
+		response.Errors = []parser.RowError{}
+	}
+
+	WriteJSON(w, http.StatusOK, "SUCCESS", "Statement reconciled", response)
+}
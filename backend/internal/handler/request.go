@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"flip-test/internal/domain"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const actorHeader = "X-User"
+
+// requestActor identifies who made the request for audit fields. There is
+// no authentication yet, so callers pass their name in the X-User header.
+func requestActor(req *http.Request) string {
+	if actor := strings.TrimSpace(req.Header.Get(actorHeader)); actor != "" {
+		return actor
+	}
+	return "anonymous"
+}
+
+// parseTimeParam accepts RFC3339, a plain "2006-01-02" date (UTC midnight)
+// or Unix seconds.
+func parseTimeParam(name string, value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed.UTC(), nil
+	}
+	if parsed, err := time.Parse("2006-01-02", value); err == nil {
+		return parsed, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid %s '%s': must be RFC3339, YYYY-MM-DD or Unix seconds", name, value)
+}
+
+// parseUpperTimeParam parses an inclusive upper bound such as to or as_of.
+// A plain date means the last instant of that day, so the whole day is
+// included.
+func parseUpperTimeParam(name string, value string) (time.Time, error) {
+	if parsed, err := time.Parse("2006-01-02", value); err == nil {
+		return parsed.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return parseTimeParam(name, value)
+}
+
+// queryValues returns every value of a repeatable query parameter, also
+// splitting comma-separated values.
+func queryValues(req *http.Request, name string) []string {
+	var values []string
+	for _, value := range req.URL.Query()[name] {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	}
+	return values
+}
+
+// currencyParam reads the currency query parameter, defaulting to
+// domain.DefaultCurrency.
+func currencyParam(req *http.Request) (domain.Currency, error) {
+	value := req.URL.Query().Get("currency")
+	if value == "" {
+		return domain.DefaultCurrency, nil
+	}
+
+	currency, ok := domain.ParseCurrency(value)
+	if !ok {
+		return "", fmt.Errorf("unsupported currency '%s'", value)
+	}
+	return currency, nil
+}
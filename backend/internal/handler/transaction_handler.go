@@ -1,80 +1,601 @@
 package handler
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flip-test/internal/domain"
 	"flip-test/internal/parser"
 	"flip-test/internal/service"
+	"fmt"
+	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
+type UploadCSVResponse struct {
+	UploadID   uuid.UUID         `json:"upload_id"`
+	Inserted   int               `json:"inserted"`
+	Duplicates int               `json:"duplicates"`
+	Conflicts  int               `json:"conflicts"`
+	Rejected   int               `json:"rejected"`
+	Errors     []parser.RowError `json:"errors"`
+}
+
+// CurrencyBalance reports Balance, Credits and Debits over successful
+// transactions; ByStatus has the same totals for every status.
+type CurrencyBalance struct {
+	Currency   domain.Currency                                   `json:"currency"`
+	Balance    int64                                             `json:"balance"`
+	Credits    int64                                             `json:"credits"`
+	Debits     int64                                             `json:"debits"`
+	MinorUnits int                                               `json:"minor_units"`
+	ByStatus   map[domain.TransactionStatus]domain.BalanceTotals `json:"by_status,omitempty"`
+}
+
+type UpdateStatusRequest struct {
+	Status domain.TransactionStatus `json:"status"`
+	Reason string                   `json:"reason"`
+}
+
 type TransactionHandler struct {
 	TransactionService *service.TransactionService
+	UploadService      *service.UploadService
+	ParseOptions       parser.Options
 }
 
-func NewTransactionHandler(ts *service.TransactionService) *TransactionHandler {
+func NewTransactionHandler(ts *service.TransactionService, us *service.UploadService, parseOptions parser.Options) *TransactionHandler {
 	return &TransactionHandler{
 		TransactionService: ts,
+		UploadService:      us,
+		ParseOptions:       parseOptions,
 	}
 }
 
 func (th *TransactionHandler) UploadCSV(w http.ResponseWriter, req *http.Request) {
+	checksum := sha256.New()
+	result, header, ok := parseCSVForm(w, req, th.ParseOptions, checksum)
+	if !ok {
+		return
+	}
+
+	transactions := result.Transactions
+	log.Printf("Parsed %d transactions from CSV, rejected %d rows", len(transactions), len(result.Errors))
+
+	upload, err := th.UploadService.Import(domain.Upload{
+		ID:         uuid.New(),
+		Filename:   header.Filename,
+		Size:       header.Size,
+		Checksum:   hex.EncodeToString(checksum.Sum(nil)),
+		UploadedBy: requestActor(req),
+		UploadedAt: time.Now().UTC(),
+	}, transactions, len(result.Errors))
+	if errors.Is(err, service.ErrInvalidReversal) {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+	if errors.Is(err, service.ErrAlreadyReversed) {
+		WriteJSON(w, http.StatusConflict, "CONFLICT", err.Error(), nil)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to save transactions: %v", err)
+		WriteJSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save transactions", nil)
+		return
+	}
+
+	log.Printf("Saved upload %s: %d inserted, %d duplicates, %d conflicts", upload.ID, upload.Inserted, upload.Duplicates, upload.Conflicts)
+
+	response := UploadCSVResponse{
+		UploadID:   upload.ID,
+		Inserted:   upload.Inserted,
+		Duplicates: upload.Duplicates,
+		Conflicts:  upload.Conflicts,
+		Rejected:   upload.Rejected,
+		Errors:     result.Errors,
+	}
+	if response.Errors == nil {
+		response.Errors = []parser.RowError{}
+	}
+
+	message := "Transactions uploaded successfully"
+	if response.Rejected > 0 || response.Conflicts > 0 {
+		message = fmt.Sprintf("Transactions uploaded with %d rejected rows and %d conflicts", response.Rejected, response.Conflicts)
+	}
+
+	WriteJSON(w, http.StatusOK, "SUCCESS", message, response)
+}
+
+// GetBalance returns the current balance per currency. With as_of it
+// returns the balance at that instant, and with from and/or to the opening
+// balance, movement and closing balance of that period.
+func (th *TransactionHandler) GetBalance(w http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+	times := make(map[string]time.Time)
+	for _, name := range []string{"as_of", "from", "to"} {
+		if value := params.Get(name); value != "" {
+			parse := parseUpperTimeParam
+			if name == "from" {
+				parse = parseTimeParam
+			}
+			parsed, err := parse(name, value)
+			if err != nil {
+				WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+				return
+			}
+			times[name] = parsed
+		}
+	}
+
+	asOf, hasAsOf := times["as_of"]
+	from, to := times["from"], times["to"]
+	switch {
+	case hasAsOf && (!from.IsZero() || !to.IsZero()):
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", "as_of cannot be combined with from or to", nil)
+	case hasAsOf:
+		th.writeBalanceAsOf(w, asOf)
+	case !from.IsZero() && !to.IsZero() && from.After(to):
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", "from must not be after to", nil)
+	case !from.IsZero() || !to.IsZero():
+		WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", th.TransactionService.GetPeriodBalance(from, to))
+	default:
+		th.writeCurrentBalance(w)
+	}
+}
+
+func (th *TransactionHandler) writeBalanceAsOf(w http.ResponseWriter, asOf time.Time) {
+	totals := th.TransactionService.GetBalanceAsOf(asOf)
+
+	response := make([]CurrencyBalance, 0, len(totals))
+	for currency, total := range totals {
+		minorUnits, _ := currency.MinorUnits()
+		response = append(response, CurrencyBalance{
+			Currency:   currency,
+			Balance:    total.Net,
+			Credits:    total.Credits,
+			Debits:     total.Debits,
+			MinorUnits: minorUnits,
+		})
+	}
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].Currency < response[j].Currency
+	})
+
+	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", response)
+}
+
+func (th *TransactionHandler) writeCurrentBalance(w http.ResponseWriter) {
+	summary := th.TransactionService.GetBalanceSummary()
+
+	response := make([]CurrencyBalance, 0, len(summary))
+	for currency, byStatus := range summary {
+		minorUnits, _ := currency.MinorUnits()
+		success := byStatus[domain.TransactionStatusSuccess]
+		response = append(response, CurrencyBalance{
+			Currency:   currency,
+			Balance:    success.Net,
+			Credits:    success.Credits,
+			Debits:     success.Debits,
+			MinorUnits: minorUnits,
+			ByStatus:   byStatus,
+		})
+	}
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].Currency < response[j].Currency
+	})
+
+	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", response)
+}
+
+func (th *TransactionHandler) UpdateStatus(w http.ResponseWriter, req *http.Request) {
+	id, err := uuid.Parse(req.PathValue("id"))
+	if err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid transaction ID", nil)
+		return
+	}
+
+	var body UpdateStatusRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body", nil)
+		return
+	}
+
+	transaction, err := th.TransactionService.UpdateStatus(id, body.Status, body.Reason, requestActor(req))
+	switch {
+	case errors.Is(err, service.ErrTransactionNotFound):
+		WriteJSON(w, http.StatusNotFound, "NOT_FOUND", err.Error(), nil)
+	case errors.Is(err, service.ErrInvalidStatusTransition):
+		WriteJSON(w, http.StatusConflict, "CONFLICT", err.Error(), nil)
+	case errors.Is(err, service.ErrInvalidStatus), errors.Is(err, service.ErrStatusReasonRequired):
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+	case err != nil:
+		log.Printf("Failed to update status of transaction %s: %v", id, err)
+		WriteJSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update transaction status", nil)
+	default:
+		WriteJSON(w, http.StatusOK, "SUCCESS", "Transaction status updated", transaction)
+	}
+}
+
+// GetPendingAging groups pending transactions by age at as_of (default now)
+// into the buckets given as upper bounds in days, e.g. buckets=2,7,30.
+func (th *TransactionHandler) GetPendingAging(w http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+
+	reference := time.Now().UTC()
+	if value := params.Get("as_of"); value != "" {
+		parsed, err := parseTimeParam("as_of", value)
+		if err != nil {
+			WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+			return
+		}
+		reference = parsed
+	}
+
+	bounds := th.TransactionService.AgingBuckets
+	if value := params.Get("buckets"); value != "" {
+		parsed, err := service.ParseAgingBuckets(value)
+		if err != nil {
+			WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+			return
+		}
+		bounds = parsed
+	}
+
+	report, err := th.TransactionService.GetPendingAging(reference, bounds)
+	if err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", report)
+}
+
+// ExpirePending runs pending expiry on demand. older_than_days defaults to
+// PENDING_EXPIRY_DAYS and dry_run=true only reports what would expire.
+func (th *TransactionHandler) ExpirePending(w http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+
+	days := th.TransactionService.PendingExpiryDays
+	if value := params.Get("older_than_days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", fmt.Sprintf("invalid older_than_days '%s'", value), nil)
+			return
+		}
+		days = parsed
+	}
+
+	dryRun := false
+	if value := params.Get("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", fmt.Sprintf("invalid dry_run '%s': must be true or false", value), nil)
+			return
+		}
+		dryRun = parsed
+	}
+
+	result, err := th.TransactionService.ExpirePending(req.Context(), time.Now().UTC(), days, dryRun)
+	if errors.Is(err, service.ErrInvalidExpiryAge) {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to expire pending transactions: %v", err)
+		WriteJSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to expire pending transactions", nil)
+		return
+	}
+
+	message := fmt.Sprintf("%d pending transactions expired", len(result.Expired))
+	if dryRun {
+		message = fmt.Sprintf("%d pending transactions would expire", len(result.Expired))
+	}
+	WriteJSON(w, http.StatusOK, "SUCCESS", message, result)
+}
+
+func (th *TransactionHandler) GetReversals(w http.ResponseWriter, req *http.Request) {
+	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", th.TransactionService.GetReversals())
+}
+
+func (th *TransactionHandler) GetUnsuccessfulTransactions(w http.ResponseWriter, req *http.Request) {
+	transactions := th.TransactionService.GetUnsuccessfulTransactions()
+	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", transactions)
+}
+
+// GetBalanceSeries returns the running balance bucketed by interval (day,
+// week or month) in the tz time zone, which defaults to DEFAULT_TIMEZONE.
+func (th *TransactionHandler) GetBalanceSeries(w http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+
+	currency, err := currencyParam(req)
+	if err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+
+	interval := domain.BucketDay
+	if value := params.Get("interval"); value != "" {
+		var ok bool
+		if interval, ok = domain.ParseBucketInterval(value); !ok {
+			WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", fmt.Sprintf("invalid interval '%s'. Must be 'day', 'week' or 'month'", value), nil)
+			return
+		}
+	}
+
+	location := th.ParseOptions.Location
+	if value := params.Get("tz"); value != "" {
+		if location, err = time.LoadLocation(value); err != nil {
+			WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", fmt.Sprintf("invalid tz '%s'", value), nil)
+			return
+		}
+	}
+
+	var from, to time.Time
+	for name, target := range map[string]*time.Time{"from": &from, "to": &to} {
+		if value := params.Get(name); value != "" {
+			parse := parseTimeParam
+			if name == "to" {
+				parse = parseUpperTimeParam
+			}
+			if *target, err = parse(name, value); err != nil {
+				WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+				return
+			}
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", "from must not be after to", nil)
+		return
+	}
+
+	series, err := th.TransactionService.GetBalanceSeries(currency, interval, location, from, to)
+	if err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", series)
+}
+
+// GetOverdrafts lists the periods the balance of a currency spent below
+// floor, which defaults to OVERDRAFT_FLOOR.
+func (th *TransactionHandler) GetOverdrafts(w http.ResponseWriter, req *http.Request) {
+	currency, err := currencyParam(req)
+	if err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+
+	floor := th.TransactionService.OverdraftFloor
+	if value := req.URL.Query().Get("floor"); value != "" {
+		if floor, err = strconv.ParseInt(value, 10, 64); err != nil {
+			WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", fmt.Sprintf("invalid floor '%s': must be an integer in minor units", value), nil)
+			return
+		}
+	}
+
+	overdrafts, err := th.TransactionService.GetOverdrafts(currency, floor)
+	if err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", overdrafts)
+}
+
+func (th *TransactionHandler) GetBalanceBreakdown(w http.ResponseWriter, req *http.Request) {
+	currency, err := currencyParam(req)
+	if err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+
+	breakdown, err := th.TransactionService.GetBalanceBreakdown(currency)
+	if err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", breakdown)
+}
+
+func (th *TransactionHandler) GetConsolidatedBalance(w http.ResponseWriter, req *http.Request) {
+	currency, err := currencyParam(req)
+	if err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+
+	balance, err := th.TransactionService.GetConsolidatedBalance(currency)
+	if errors.Is(err, service.ErrRatesNotConfigured) {
+		WriteJSON(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", err.Error(), nil)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to consolidate balance: %v", err)
+		WriteJSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to consolidate balance", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", balance)
+}
+
+func (th *TransactionHandler) ListTransactions(w http.ResponseWriter, req *http.Request) {
+	query, err := parseTransactionQuery(req)
+	if err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+
+	page, err := th.TransactionService.ListTransactions(query, req.URL.Query().Get("cursor"))
+	if errors.Is(err, service.ErrInvalidCursor) {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to list transactions: %v", err)
+		WriteJSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list transactions", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", page)
+}
+
+func parseTransactionQuery(req *http.Request) (domain.TransactionQuery, error) {
+	params := req.URL.Query()
+	var query domain.TransactionQuery
+
+	for _, value := range queryValues(req, "status") {
+		status := domain.TransactionStatus(strings.ToUpper(value))
+		if !status.IsValid() {
+			return query, fmt.Errorf("invalid status '%s'", value)
+		}
+		query.Filter.Statuses = append(query.Filter.Statuses, status)
+	}
+
+	for _, value := range queryValues(req, "type") {
+		transactionType := domain.TransactionType(strings.ToUpper(value))
+		if transactionType != domain.TransactionTypeCredit && transactionType != domain.TransactionTypeDebit {
+			return query, fmt.Errorf("invalid type '%s'", value)
+		}
+		query.Filter.Types = append(query.Filter.Types, transactionType)
+	}
+
+	query.Filter.Name = strings.TrimSpace(params.Get("name"))
+
+	if value := params.Get("currency"); value != "" {
+		currency, ok := domain.ParseCurrency(value)
+		if !ok {
+			return query, fmt.Errorf("unsupported currency '%s'", value)
+		}
+		query.Filter.Currency = currency
+	}
+
+	for name, target := range map[string]**int64{"min_amount": &query.Filter.MinAmount, "max_amount": &query.Filter.MaxAmount} {
+		if value := params.Get(name); value != "" {
+			amount, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return query, fmt.Errorf("invalid %s '%s': must be an integer in minor units", name, value)
+			}
+			*target = &amount
+		}
+	}
+
+	for name, target := range map[string]*time.Time{"from": &query.Filter.From, "to": &query.Filter.To} {
+		if value := params.Get(name); value != "" {
+			parse := parseTimeParam
+			if name == "to" {
+				parse = parseUpperTimeParam
+			}
+			parsed, err := parse(name, value)
+			if err != nil {
+				return query, err
+			}
+			*target = parsed
+		}
+	}
+
+	if value := params.Get("upload_id"); value != "" {
+		uploadID, err := uuid.Parse(value)
+		if err != nil {
+			return query, fmt.Errorf("invalid upload_id '%s'", value)
+		}
+		query.Filter.UploadID = uploadID
+	}
+
+	// sort=amount sorts ascending, sort=-amount descending.
+	if value := params.Get("sort"); value != "" {
+		field, ok := domain.ParseTransactionSortField(strings.TrimPrefix(value, "-"))
+		if !ok {
+			return query, fmt.Errorf("invalid sort field '%s'", strings.TrimPrefix(value, "-"))
+		}
+		query.SortBy = field
+		query.Descending = strings.HasPrefix(value, "-")
+	}
+
+	if value := params.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return query, fmt.Errorf("invalid limit '%s': must be a positive integer", value)
+		}
+		query.Limit = limit
+	}
+
+	return query, nil
+}
+
+// parseCSVForm reads the CSV in the "file" field of a multipart form using
+// opts with the mode and locale form values applied, copying the raw file
+// to checksum when it is not nil. It writes the error response itself and
+// reports whether parsing succeeded.
+func parseCSVForm(w http.ResponseWriter, req *http.Request, opts parser.Options, checksum io.Writer) (parser.Result, *multipart.FileHeader, bool) {
 	// Parse the multipart form with 10 MB memory limit
 	err := req.ParseMultipartForm(10 << 20)
 	if err != nil {
 		log.Printf("Failed to parse multipart form: %v", err)
 		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", "Failed to parse form", nil)
-		return
+		return parser.Result{}, nil, false
 	}
 
 	file, header, err := req.FormFile("file")
 	if err != nil {
 		log.Printf("Failed to get file from form: %v", err)
 		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", "CSV file is required", nil)
-		return
+		return parser.Result{}, nil, false
 	}
 	defer file.Close()
 
 	if !strings.HasSuffix(strings.ToLower(header.Filename), ".csv") {
 		log.Printf("Invalid file extension: %s", header.Filename)
 		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", "Only CSV files are allowed", nil)
-		return
+		return parser.Result{}, nil, false
 	}
 
 	if header.Size > 10<<20 {
 		log.Printf("File too large: %d bytes", header.Size)
 		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", "File size must be less than 10MB", nil)
-		return
+		return parser.Result{}, nil, false
 	}
 
-	log.Printf("Processing CSV file: %s (size: %d bytes)", header.Filename, header.Size)
-
-	transactions, err := parser.ParseCSVToTransactions(file)
+	mode, err := parser.ParseMode(req.FormValue("mode"))
 	if err != nil {
-		log.Printf("Failed to parse CSV: %v", err)
+		log.Printf("Invalid CSV mode: %v", err)
 		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
-		return
+		return parser.Result{}, nil, false
 	}
 
-	log.Printf("Parsed %d transactions from CSV", len(transactions))
+	parseOptions := opts
+	parseOptions.Mode = mode
 
-	err = th.TransactionService.SaveTransactions(transactions)
-	if err != nil {
-		log.Printf("Failed to save transactions: %v", err)
-		WriteJSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save transactions", nil)
-		return
+	if locale := req.FormValue("locale"); locale != "" {
+		parseOptions.Locale, err = parser.ParseLocale(locale)
+		if err != nil {
+			log.Printf("Invalid CSV locale: %v", err)
+			WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+			return parser.Result{}, nil, false
+		}
 	}
 
-	log.Printf("Successfully saved %d transactions", len(transactions))
-	WriteJSON(w, http.StatusOK, "SUCCESS", "Transactions uploaded successfully", nil)
-}
+	log.Printf("Processing CSV file: %s (size: %d bytes, mode: %s, locale: %s)", header.Filename, header.Size, mode, parseOptions.Locale.Name)
 
-func (th *TransactionHandler) GetBalance(w http.ResponseWriter, req *http.Request) {
-	balance := th.TransactionService.GetBalance()
-	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", balance)
-}
+	var reader io.Reader = file
+	if checksum != nil {
+		reader = io.TeeReader(file, checksum)
+	}
+	result, err := parser.ParseCSV(reader, parseOptions)
+	if err != nil {
+		log.Printf("Failed to parse CSV: %v", err)
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return parser.Result{}, nil, false
+	}
 
-func (th *TransactionHandler) GetUnsuccessfulTransactions(w http.ResponseWriter, req *http.Request) {
-	transactions := th.TransactionService.GetUnsuccessfulTransactions()
-	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", transactions)
+	return result, header, true
 }
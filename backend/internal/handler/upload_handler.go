@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"flip-test/internal/domain"
+	"flip-test/internal/service"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type UploadDetailResponse struct {
+	domain.Upload
+	Transactions []domain.Transaction `json:"transactions"`
+}
+
+type RollbackUploadRequest struct {
+	Reason string `json:"reason"`
+}
+
+type UploadHandler struct {
+	UploadService *service.UploadService
+}
+
+func NewUploadHandler(us *service.UploadService) *UploadHandler {
+	return &UploadHandler{
+		UploadService: us,
+	}
+}
+
+func (uh *UploadHandler) GetUploads(w http.ResponseWriter, req *http.Request) {
+	uploads := uh.UploadService.GetUploads()
+	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", uploads)
+}
+
+func (uh *UploadHandler) GetUpload(w http.ResponseWriter, req *http.Request) {
+	id, err := uuid.Parse(req.PathValue("id"))
+	if err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid upload ID", nil)
+		return
+	}
+
+	upload, transactions, err := uh.UploadService.GetUpload(id)
+	if errors.Is(err, service.ErrUploadNotFound) {
+		WriteJSON(w, http.StatusNotFound, "NOT_FOUND", err.Error(), nil)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to get upload %s: %v", id, err)
+		WriteJSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get upload", nil)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, "SUCCESS", "SUCCESS", UploadDetailResponse{
+		Upload:       upload,
+		Transactions: transactions,
+	})
+}
+
+func (uh *UploadHandler) RollbackUpload(w http.ResponseWriter, req *http.Request) {
+	id, err := uuid.Parse(req.PathValue("id"))
+	if err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid upload ID", nil)
+		return
+	}
+
+	var body RollbackUploadRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body", nil)
+		return
+	}
+
+	upload, err := uh.UploadService.Rollback(id, requestActor(req), body.Reason)
+	switch {
+	case errors.Is(err, service.ErrRollbackReasonRequired):
+		WriteJSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	case errors.Is(err, service.ErrUploadNotFound):
+		WriteJSON(w, http.StatusNotFound, "NOT_FOUND", err.Error(), nil)
+		return
+	case errors.Is(err, service.ErrUploadRolledBack), errors.Is(err, service.ErrUploadReversed):
+		WriteJSON(w, http.StatusConflict, "CONFLICT", err.Error(), nil)
+		return
+	case err != nil:
+		log.Printf("Failed to roll back upload %s: %v", id, err)
+		WriteJSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to roll back upload", nil)
+		return
+	}
+
+	log.Printf("Upload %s rolled back by %s: %d transactions removed", id, upload.Rollback.RolledBackBy, upload.Rollback.TransactionsRemoved)
+	WriteJSON(w, http.StatusOK, "SUCCESS", "Upload rolled back successfully", upload)
+}
@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Locale struct {
+	Name               string
+	ThousandsSeparator rune
+	DecimalSeparator   rune
+}
+
+var (
+	LocaleEN = Locale{Name: "en", ThousandsSeparator: ',', DecimalSeparator: '.'}
+	LocaleID = Locale{Name: "id", ThousandsSeparator: '.', DecimalSeparator: ','}
+)
+
+func ParseLocale(name string) (Locale, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", LocaleEN.Name:
+		return LocaleEN, nil
+	case LocaleID.Name:
+		return LocaleID, nil
+	default:
+		return Locale{}, fmt.Errorf("invalid locale '%s'. Must be 'en' or 'id'", name)
+	}
+}
+
+// parseAmount converts a locale-formatted decimal string into minor units
+// with the given scale, without going through floating point.
+func parseAmount(value string, locale Locale, scale int) (int64, error) {
+	if locale == (Locale{}) {
+		locale = LocaleEN
+	}
+
+	value = strings.ReplaceAll(strings.TrimSpace(value), " ", "")
+	if value == "" {
+		return 0, fmt.Errorf("amount is empty")
+	}
+
+	sign := ""
+	if value[0] == '-' || value[0] == '+' {
+		if value[0] == '-' {
+			sign = "-"
+		}
+		value = value[1:]
+	}
+
+	integerPart, fractionPart, hasFraction := strings.Cut(value, string(locale.DecimalSeparator))
+	if hasFraction && strings.ContainsRune(fractionPart, locale.DecimalSeparator) {
+		return 0, fmt.Errorf("more than one decimal separator '%c'", locale.DecimalSeparator)
+	}
+
+	integerDigits, err := stripThousandsSeparators(integerPart, locale.ThousandsSeparator)
+	if err != nil {
+		return 0, err
+	}
+	if integerDigits == "" && fractionPart == "" {
+		return 0, fmt.Errorf("no digits")
+	}
+	if !isDigits(fractionPart) {
+		return 0, fmt.Errorf("invalid decimal digits '%s'", fractionPart)
+	}
+
+	if len(fractionPart) > scale {
+		if strings.Trim(fractionPart[scale:], "0") != "" {
+			return 0, fmt.Errorf("more than %d decimal places", scale)
+		}
+		fractionPart = fractionPart[:scale]
+	}
+	fractionPart += strings.Repeat("0", scale-len(fractionPart))
+
+	minorUnits, err := strconv.ParseInt(sign+integerDigits+fractionPart, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("out of range")
+	}
+
+	return minorUnits, nil
+}
+
+func stripThousandsSeparators(value string, separator rune) (string, error) {
+	groups := strings.Split(value, string(separator))
+	for i, group := range groups {
+		if !isDigits(group) {
+			return "", fmt.Errorf("invalid digits '%s'", value)
+		}
+		if len(groups) == 1 {
+			break
+		}
+		if (i == 0 && (len(group) == 0 || len(group) > 3)) || (i > 0 && len(group) != 3) {
+			return "", fmt.Errorf("misplaced thousands separator '%c' in '%s'", separator, value)
+		}
+	}
+
+	return strings.Join(groups, ""), nil
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAmount_Locales(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		locale   Locale
+		scale    int
+		expected int64
+	}{
+		{"plain integer", "1000000", LocaleEN, 0, 1000000},
+		{"en thousands", "1,000,000", LocaleEN, 0, 1000000},
+		{"en decimal", "250000.75", LocaleEN, 2, 25000075},
+		{"en thousands and decimal", "1,250,000.5", LocaleEN, 2, 125000050},
+		{"id thousands", "1.000.000", LocaleID, 0, 1000000},
+		{"id thousands and decimal", "1.000.000,50", LocaleID, 2, 100000050},
+		{"zero fraction beyond scale", "1.000.000,00", LocaleID, 0, 1000000},
+		{"default locale", "1,000", Locale{}, 0, 1000},
+		{"fraction only", ".5", LocaleEN, 2, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, err := parseAmount(tt.value, tt.locale, tt.scale)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			if amount != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, amount)
+			}
+		})
+	}
+}
+
+func TestParseAmount_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		locale Locale
+		scale  int
+	}{
+		{"not a number", "not_a_number", LocaleEN, 0},
+		{"too many decimals", "250000.75", LocaleEN, 0},
+		{"misplaced thousands separator", "250000,75", LocaleEN, 2},
+		{"two decimal separators", "1.000.000", LocaleEN, 2},
+		{"overflow", "99999999999999999999", LocaleEN, 0},
+		{"empty", "  ", LocaleEN, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseAmount(tt.value, tt.locale, tt.scale); err == nil {
+				t.Errorf("Expected error for '%s', got none", tt.value)
+			}
+		})
+	}
+}
+
+func TestParseCSV_LocaleFormattedAmount(t *testing.T) {
+	csvData := `timestamp,name,type,amount,status
+1704067200,John Doe,CREDIT,"1.000.000,00",SUCCESS`
+
+	result, err := ParseCSV(strings.NewReader(csvData), Options{Locale: LocaleID})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if result.Transactions[0].Amount != 100000000 {
+		t.Errorf("Expected amount 100000000 sen, got: %d", result.Transactions[0].Amount)
+	}
+}
+
+func TestParseCSV_CurrencyScale(t *testing.T) {
+	csvData := `timestamp,name,type,amount,status
+1704067200,John Doe,CREDIT,"1,250.50",SUCCESS`
+
+	result, err := ParseCSV(strings.NewReader(csvData), Options{Currency: "USD"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if result.Transactions[0].Amount != 125050 {
+		t.Errorf("Expected amount 125050 cents, got: %d", result.Transactions[0].Amount)
+	}
+
+	_, err = ParseCSV(strings.NewReader(csvData), Options{Currency: "JPY"})
+	if err == nil || !strings.Contains(err.Error(), "invalid amount") {
+		t.Errorf("Expected invalid amount error for fractional JPY, got: %v", err)
+	}
+}
+
+func TestParseCSV_FractionalRupiah(t *testing.T) {
+	tests := []struct {
+		amount   string
+		locale   Locale
+		expected int64
+	}{
+		{`"1.000.000,50"`, LocaleID, 100000050},
+		{"250000.75", LocaleEN, 25000075},
+	}
+
+	for _, tt := range tests {
+		csvData := "timestamp,name,type,amount,status\n1704067200,John Doe,CREDIT," + tt.amount + ",SUCCESS"
+		result, err := ParseCSV(strings.NewReader(csvData), Options{Locale: tt.locale})
+		if err != nil {
+			t.Fatalf("Expected no error for %s, got: %v", tt.amount, err)
+		}
+		if result.Transactions[0].Amount != tt.expected {
+			t.Errorf("Expected %s to be %d sen, got: %d", tt.amount, tt.expected, result.Transactions[0].Amount)
+		}
+	}
+}
+
+func TestParseLocale(t *testing.T) {
+	if locale, err := ParseLocale("ID"); err != nil || locale != LocaleID {
+		t.Errorf("Expected id locale, got: %+v, %v", locale, err)
+	}
+
+	if _, err := ParseLocale("fr"); err == nil {
+		t.Error("Expected error for unknown locale, got none")
+	}
+}
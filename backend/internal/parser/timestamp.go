@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimestampLayouts are tried in order when Options.TimestampLayouts is
+// empty. Slash dates are read day-first, which is how our Indonesian partners
+// export them; configure a month-first layout explicitly when needed.
+var DefaultTimestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	"02/01/2006",
+}
+
+// Epoch values at or above this magnitude are treated as milliseconds.
+const epochMillisThreshold = 1_000_000_000_000
+
+func parseTimestamp(value string, layouts []string, location *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("timestamp is empty")
+	}
+
+	if len(layouts) == 0 {
+		layouts = DefaultTimestampLayouts
+	}
+	if location == nil {
+		location = time.UTC
+	}
+
+	for _, layout := range layouts {
+		// ParseInLocation keeps an explicit offset in the value and only
+		// falls back to location when the value has none.
+		parsed, err := time.ParseInLocation(layout, value, location)
+		if err == nil {
+			return parsed.UTC(), nil
+		}
+	}
+
+	// Epochs are tried after the layouts so a numeric layout such as
+	// "20060102" is not read as Unix seconds.
+	if epoch, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if epoch >= epochMillisThreshold || epoch <= -epochMillisThreshold {
+			return time.UnixMilli(epoch).UTC(), nil
+		}
+		return time.Unix(epoch, 0).UTC(), nil
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized format, expected Unix seconds, Unix milliseconds or one of: %s", strings.Join(layouts, ", "))
+}
+
+// ParseTimestampLayouts reads a "|"-separated list of Go time layouts.
+func ParseTimestampLayouts(value string) []string {
+	var layouts []string
+	for _, layout := range strings.Split(value, "|") {
+		if layout = strings.TrimSpace(layout); layout != "" {
+			layouts = append(layouts, layout)
+		}
+	}
+	return layouts
+}
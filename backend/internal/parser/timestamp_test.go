@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp_Formats(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Time
+	}{
+		{"unix seconds", "1704067200", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"unix milliseconds", "1704067200123", time.Date(2024, 1, 1, 0, 0, 0, 123_000_000, time.UTC)},
+		{"rfc3339 with offset", "2024-01-01T13:45:00+02:00", time.Date(2024, 1, 1, 11, 45, 0, 0, time.UTC)},
+		{"rfc3339 utc", "2024-01-01T13:45:00Z", time.Date(2024, 1, 1, 13, 45, 0, 0, time.UTC)},
+		{"datetime without zone", "2024-01-01 13:45:00", time.Date(2024, 1, 1, 6, 45, 0, 0, time.UTC)},
+		{"date only", "2024-01-01", time.Date(2023, 12, 31, 17, 0, 0, 0, time.UTC)},
+		{"day-first slash date", "01/02/2024", time.Date(2024, 1, 31, 17, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := parseTimestamp(tt.value, nil, jakarta)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			if !parsed.Equal(tt.expected) {
+				t.Errorf("Expected %s, got %s", tt.expected, parsed)
+			}
+		})
+	}
+}
+
+func TestParseTimestamp_CustomLayouts(t *testing.T) {
+	parsed, err := parseTimestamp("01/02/2024", []string{"01/02/2006"}, time.UTC)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	if !parsed.Equal(expected) {
+		t.Errorf("Expected month-first layout to give %s, got %s", expected, parsed)
+	}
+
+	if _, err := parseTimestamp("2024-01-02", []string{"01/02/2006"}, time.UTC); err == nil {
+		t.Error("Expected error for value not matching custom layouts, got none")
+	}
+}
+
+func TestParseTimestamp_NumericLayoutBeforeEpoch(t *testing.T) {
+	layouts := []string{"20060102"}
+
+	parsed, err := parseTimestamp("20240131", layouts, time.UTC)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if expected := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC); !parsed.Equal(expected) {
+		t.Errorf("Expected the numeric layout to give %s, got %s", expected, parsed)
+	}
+
+	parsed, err = parseTimestamp("1704067200", layouts, time.UTC)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if expected := time.Unix(1704067200, 0).UTC(); !parsed.Equal(expected) {
+		t.Errorf("Expected values not matching the layout to fall back to Unix seconds, got %s", parsed)
+	}
+}
+
+func TestParseTimestamp_DefaultsToUTC(t *testing.T) {
+	parsed, err := parseTimestamp("2024-01-01 13:45:00", nil, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := time.Date(2024, 1, 1, 13, 45, 0, 0, time.UTC)
+	if !parsed.Equal(expected) {
+		t.Errorf("Expected %s, got %s", expected, parsed)
+	}
+}
+
+func TestParseCSV_TimestampLocation(t *testing.T) {
+	csvData := `timestamp,name,type,amount,status
+2024-01-01 07:00:00,John Doe,CREDIT,1000000,SUCCESS`
+
+	result, err := ParseCSV(strings.NewReader(csvData), Options{Location: time.FixedZone("WIB", 7*60*60)})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if !result.Transactions[0].TransactionDate.Equal(expected) {
+		t.Errorf("Expected %s, got %s", expected, result.Transactions[0].TransactionDate)
+	}
+}
+
+func TestParseTimestampLayouts(t *testing.T) {
+	layouts := ParseTimestampLayouts("2006-01-02 | 01/02/2006 |")
+	if len(layouts) != 2 || layouts[0] != "2006-01-02" || layouts[1] != "01/02/2006" {
+		t.Errorf("Unexpected layouts: %q", layouts)
+	}
+}
@@ -2,9 +2,9 @@ package parser
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
-	"strconv"
 	"strings"
 	"time"
 
@@ -13,19 +13,102 @@ import (
 	"github.com/google/uuid"
 )
 
-var expectedHeaders = []string{"timestamp", "name", "type", "amount", "status", "description"}
+var requiredColumns = []string{"timestamp", "name", "type", "amount", "status"}
+var optionalColumns = []string{"description", "currency", "reference", "reverses"}
+
+// DefaultAliases maps alternative header names used by partners to the
+// canonical column names. Options.Aliases takes precedence over it.
+var DefaultAliases = map[string]string{
+	"date":         "timestamp",
+	"datetime":     "timestamp",
+	"time":         "timestamp",
+	"counterparty": "name",
+	"direction":    "type",
+	"value":        "amount",
+	"state":        "status",
+	"note":         "description",
+	"memo":         "description",
+	"ccy":          "currency",
+	"ref":          "reference",
+	"reference_id": "reference",
+	"external_id":  "reference",
+	"reversal_of":  "reverses",
+	"refund_of":    "reverses",
+}
+
+type Mode string
+
+const (
+	// ModeStrict aborts on the first invalid row.
+	ModeStrict Mode = "strict"
+	// ModeLenient skips invalid rows and reports every one of them.
+	ModeLenient Mode = "lenient"
+)
+
+func ParseMode(value string) (Mode, error) {
+	switch Mode(strings.ToLower(strings.TrimSpace(value))) {
+	case "", ModeStrict:
+		return ModeStrict, nil
+	case ModeLenient:
+		return ModeLenient, nil
+	default:
+		return "", fmt.Errorf("invalid mode '%s'. Must be 'strict' or 'lenient'", value)
+	}
+}
+
+type Options struct {
+	Mode    Mode
+	Aliases map[string]string
+	// TimestampLayouts overrides DefaultTimestampLayouts.
+	TimestampLayouts []string
+	// Location is applied to timestamps without an explicit zone. Defaults to UTC.
+	Location *time.Location
+	// Locale decides the thousands and decimal separators of amounts. Defaults to LocaleEN.
+	Locale Locale
+	// Currency is used for rows without a currency column value. Defaults to domain.DefaultCurrency.
+	Currency domain.Currency
+	// NameAliases maps the domain.CounterpartyKey of a name variant to the
+	// name stored instead. See LoadNameAliases.
+	NameAliases map[string]string
+}
+
+type RowError struct {
+	Line   int    `json:"line"`
+	Column string `json:"column,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+}
+
+type Result struct {
+	Transactions []domain.Transaction
+	// Lines holds the file line of each transaction, in the same order.
+	Lines  []int
+	Errors []RowError
+}
 
 func ParseCSVToTransactions(r io.Reader) ([]domain.Transaction, error) {
+	result, err := ParseCSV(r, Options{Mode: ModeStrict})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Transactions, nil
+}
+
+func ParseCSV(r io.Reader, opts Options) (Result, error) {
 	reader := csv.NewReader(r)
 
-	if err := validateCSVHeaders(reader); err != nil {
-		return nil, err
+	columns, err := readColumnMap(reader, opts.Aliases)
+	if err != nil {
+		return Result{}, err
 	}
 
-	var transactions []domain.Transaction
-	lineNum := 1 // Header is line 1, data starts at line 2
+	var result Result
 	for {
-		lineNum++
 		record, err := reader.Read()
 
 		if err == io.EOF {
@@ -33,89 +116,287 @@ func ParseCSVToTransactions(r io.Reader) ([]domain.Transaction, error) {
 		}
 
 		if err != nil {
-			return nil, fmt.Errorf("line %d: failed to read row: %w", lineNum, err)
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return Result{}, fmt.Errorf("failed to read row: %w", err)
+			}
+			if opts.Mode != ModeLenient {
+				return Result{}, fmt.Errorf("line %d: failed to read row: %w", parseErr.StartLine, err)
+			}
+
+			result.Errors = append(result.Errors, RowError{
+				Line:   parseErr.StartLine,
+				Reason: fmt.Sprintf("failed to read row: %v", parseErr.Err),
+			})
+			continue
 		}
 
-		transaction, err := parseTransactionRow(record, lineNum)
+		// Lines are taken from the reader rather than counted, since a quoted
+		// field may span several lines.
+		lineNum, _ := reader.FieldPos(0)
+		transaction, rowErr := parseTransactionRow(record, columns, opts, lineNum)
+		if rowErr != nil {
+			if opts.Mode != ModeLenient {
+				return Result{}, rowErr
+			}
+
+			result.Errors = append(result.Errors, *rowErr)
+			continue
+		}
+
+		result.Transactions = append(result.Transactions, transaction)
+		result.Lines = append(result.Lines, lineNum)
+	}
+
+	return result, nil
+}
+
+// ParseAliases reads an alias table in the form "date=timestamp,counterparty=name".
+func ParseAliases(value string) (map[string]string, error) {
+	aliases := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		alias, column, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid alias '%s': expected 'alias=column'", pair)
+		}
+
+		column = normalizeHeader(column)
+		if !isKnownColumn(column) {
+			return nil, fmt.Errorf("invalid alias '%s': unknown column '%s'", pair, column)
+		}
+
+		aliases[normalizeHeader(alias)] = column
+	}
+
+	return aliases, nil
+}
+
+// LoadNameAliases reads a CSV with the header "alias,name" mapping name
+// variants to the counterparty name to store. Variants are matched after
+// normalization and case folding.
+func LoadNameAliases(r io.Reader) (map[string]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read name alias header: %w", err)
+	}
+	if normalizeHeader(header[0]) != "alias" || normalizeHeader(header[1]) != "name" {
+		return nil, fmt.Errorf("invalid name alias header: expected 'alias,name'")
+	}
+
+	aliases := make(map[string]string)
+	for lineNum := 2; ; lineNum++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return aliases, nil
+		}
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("line %d: failed to read name alias: %w", lineNum, err)
 		}
 
-		transactions = append(transactions, transaction)
+		alias, name := domain.CounterpartyKey(record[0]), domain.NormalizeName(record[1])
+		if alias == "" || name == "" {
+			return nil, fmt.Errorf("line %d: alias and name cannot be empty", lineNum)
+		}
+		aliases[alias] = name
 	}
+}
+
+type columnMap map[string]int
 
-	return transactions, nil
+func (c columnMap) value(record []string, column string) string {
+	index, ok := c[column]
+	if !ok {
+		return ""
+	}
+	return record[index]
 }
 
-func validateCSVHeaders(reader *csv.Reader) error {
+func readColumnMap(reader *csv.Reader, aliases map[string]string) (columnMap, error) {
 	headers, err := reader.Read()
 	if err != nil {
-		return fmt.Errorf("failed to read CSV header: %w", err)
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
 	}
 
-	if len(headers) != len(expectedHeaders) {
-		return fmt.Errorf("invalid header count: expected %d columns, got %d", len(expectedHeaders), len(headers))
+	columns := make(columnMap)
+	for i, header := range headers {
+		column := resolveColumn(normalizeHeader(header), aliases)
+		if !isKnownColumn(column) {
+			continue
+		}
+
+		if _, exists := columns[column]; exists {
+			return nil, fmt.Errorf("invalid header at column %d: duplicate column '%s' ('%s')", i+1, column, header)
+		}
+		columns[column] = i
 	}
 
-	for i, header := range headers {
-		normalizedHeader := strings.ToLower(strings.TrimSpace(header))
-		if normalizedHeader != expectedHeaders[i] {
-			return fmt.Errorf("invalid header at column %d: expected '%s', got '%s'", i+1, expectedHeaders[i], header)
+	var missing []string
+	for _, column := range requiredColumns {
+		if _, ok := columns[column]; !ok {
+			missing = append(missing, column)
 		}
 	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("invalid header: missing required columns: %s", strings.Join(missing, ", "))
+	}
 
-	return nil
+	return columns, nil
+}
+
+func normalizeHeader(header string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header, "\ufeff")))
+}
+
+func resolveColumn(header string, aliases map[string]string) string {
+	if column, ok := aliases[header]; ok {
+		return column
+	}
+	if column, ok := DefaultAliases[header]; ok {
+		return column
+	}
+	return header
 }
 
-func parseTransactionRow(record []string, lineNum int) (domain.Transaction, error) {
-	if len(record) != len(expectedHeaders) {
-		return domain.Transaction{}, fmt.Errorf("line %d: expected %d columns, got %d", lineNum, len(expectedHeaders), len(record))
+func isKnownColumn(column string) bool {
+	for _, known := range requiredColumns {
+		if column == known {
+			return true
+		}
+	}
+	for _, known := range optionalColumns {
+		if column == known {
+			return true
+		}
 	}
+	return false
+}
 
-	timestamp, err := strconv.ParseInt(strings.TrimSpace(record[0]), 10, 64)
+func parseTransactionRow(record []string, columns columnMap, opts Options, lineNum int) (domain.Transaction, *RowError) {
+	rawTimestamp := columns.value(record, "timestamp")
+	transactionDate, err := parseTimestamp(rawTimestamp, opts.TimestampLayouts, opts.Location)
 	if err != nil {
-		return domain.Transaction{}, fmt.Errorf("line %d: invalid timestamp '%s': %w", lineNum, record[0], err)
+		return domain.Transaction{}, newRowError(lineNum, "timestamp", rawTimestamp, fmt.Sprintf("invalid timestamp '%s': %v", rawTimestamp, err))
+	}
+
+	rawName := columns.value(record, "name")
+	name := domain.NormalizeName(rawName)
+	if name == "" {
+		return domain.Transaction{}, newRowError(lineNum, "name", rawName, "invalid name: name cannot be empty")
 	}
-	transactionDate := time.Unix(timestamp, 0).UTC()
 
-	transactionType := domain.TransactionType(strings.TrimSpace(record[2]))
-	if err := validateTransactionType(transactionType, lineNum, record[2]); err != nil {
+	rawType := columns.value(record, "type")
+	transactionType := domain.TransactionType(strings.TrimSpace(rawType))
+	if err := validateTransactionType(transactionType, lineNum, rawType); err != nil {
 		return domain.Transaction{}, err
 	}
 
-	amount, err := strconv.ParseInt(strings.TrimSpace(record[3]), 10, 64)
+	currency, rowErr := parseCurrency(columns.value(record, "currency"), opts.Currency, lineNum)
+	if rowErr != nil {
+		return domain.Transaction{}, rowErr
+	}
+	scale, _ := currency.MinorUnits()
+
+	rawAmount := columns.value(record, "amount")
+	amount, err := parseAmount(rawAmount, opts.Locale, scale)
 	if err != nil {
-		return domain.Transaction{}, fmt.Errorf("line %d: invalid amount '%s': %w", lineNum, record[3], err)
+		return domain.Transaction{}, newRowError(lineNum, "amount", rawAmount, fmt.Sprintf("invalid amount '%s': %v", rawAmount, err))
+	}
+	if amount <= 0 {
+		return domain.Transaction{}, newRowError(lineNum, "amount", rawAmount, fmt.Sprintf("invalid amount '%s': amount must be greater than 0", rawAmount))
 	}
 
-	transactionStatus := domain.TransactionStatus(strings.TrimSpace(record[4]))
-	if err := validateTransactionStatus(transactionStatus, lineNum, record[4]); err != nil {
+	rawStatus := columns.value(record, "status")
+	transactionStatus := domain.TransactionStatus(strings.TrimSpace(rawStatus))
+	if err := validateTransactionStatus(transactionStatus, lineNum, rawStatus); err != nil {
 		return domain.Transaction{}, err
 	}
 
-	return domain.Transaction{
-		ID:              uuid.New(),
-		Name:            strings.TrimSpace(record[1]),
+	rawReverses := columns.value(record, "reverses")
+	reversalOf, rowErr := parseReverses(rawReverses, lineNum)
+	if rowErr != nil {
+		return domain.Transaction{}, rowErr
+	}
+
+	transaction := domain.Transaction{
+		Reference:       strings.TrimSpace(columns.value(record, "reference")),
+		Name:            name,
 		Type:            transactionType,
 		Amount:          amount,
+		Currency:        currency,
 		Status:          transactionStatus,
-		Description:     strings.TrimSpace(record[5]),
+		Description:     strings.TrimSpace(columns.value(record, "description")),
 		TransactionDate: transactionDate,
-	}, nil
+		ReversalOf:      reversalOf,
+	}
+	// The ID is derived before the alias is applied, so it does not depend on
+	// the alias table in use and re-imports stay duplicates when it changes.
+	transaction.ID = domain.NewTransactionID(transaction)
+	if alias, ok := opts.NameAliases[domain.CounterpartyKey(name)]; ok && alias != name {
+		transaction.Name = alias
+		transaction.AliasedFrom = name
+	}
+
+	return transaction, nil
+}
+
+func parseCurrency(value string, fallback domain.Currency, lineNum int) (domain.Currency, *RowError) {
+	if strings.TrimSpace(value) == "" {
+		if fallback == "" {
+			return domain.DefaultCurrency, nil
+		}
+		value = string(fallback)
+	}
+
+	currency, ok := domain.ParseCurrency(value)
+	if !ok {
+		return "", newRowError(lineNum, "currency", value, fmt.Sprintf("invalid currency '%s': not a supported ISO 4217 code", value))
+	}
+	return currency, nil
+}
+
+// parseReverses resolves the reverses column, which holds either the ID or
+// the external reference of the original transaction.
+func parseReverses(value string, lineNum int) (uuid.UUID, *RowError) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return uuid.Nil, nil
+	}
+
+	if id, err := uuid.Parse(value); err == nil {
+		if id == uuid.Nil {
+			return uuid.Nil, newRowError(lineNum, "reverses", value, "invalid reverses: must not be the nil ID")
+		}
+		return id, nil
+	}
+	return domain.ReferenceTransactionID(value), nil
+}
+
+func newRowError(lineNum int, column string, value string, reason string) *RowError {
+	return &RowError{
+		Line:   lineNum,
+		Column: column,
+		Value:  value,
+		Reason: reason,
+	}
 }
 
-func validateTransactionType(t domain.TransactionType, lineNum int, original string) error {
+func validateTransactionType(t domain.TransactionType, lineNum int, original string) *RowError {
 	if t != domain.TransactionTypeDebit && t != domain.TransactionTypeCredit {
-		return fmt.Errorf("line %d: invalid transaction type '%s'. Must be 'DEBIT' or 'CREDIT'", lineNum, original)
+		return newRowError(lineNum, "type", original, fmt.Sprintf("invalid transaction type '%s'. Must be 'DEBIT' or 'CREDIT'", original))
 	}
 	return nil
 }
 
-func validateTransactionStatus(s domain.TransactionStatus, lineNum int, original string) error {
-	if s != domain.TransactionStatusSuccess &&
-		s != domain.TransactionStatusPending &&
-		s != domain.TransactionStatusFailed {
-		return fmt.Errorf("line %d: invalid status '%s'. Must be 'SUCCESS', 'PENDING', or 'FAILED'", lineNum, original)
+func validateTransactionStatus(s domain.TransactionStatus, lineNum int, original string) *RowError {
+	if !s.IsValid() {
+		return newRowError(lineNum, "status", original, fmt.Sprintf("invalid status '%s'. Must be 'SUCCESS', 'PENDING', or 'FAILED'", original))
 	}
 	return nil
 }
@@ -24,14 +24,14 @@ func TestParseCSVToTransactions_ValidCSV(t *testing.T) {
 
 	// Test first transaction
 	first := transactions[0]
-	if first.Name != "John Doe" {
-		t.Errorf("Expected name 'John Doe', got: %s", first.Name)
+	if first.Name != "john doe" {
+		t.Errorf("Expected name 'john doe', got: %s", first.Name)
 	}
 	if first.Type != domain.TransactionTypeCredit {
 		t.Errorf("Expected type CREDIT, got: %s", first.Type)
 	}
-	if first.Amount != 1000000 {
-		t.Errorf("Expected amount 1000000, got: %d", first.Amount)
+	if first.Amount != 100000000 {
+		t.Errorf("Expected amount 100000000, got: %d", first.Amount)
 	}
 	if first.Status != domain.TransactionStatusSuccess {
 		t.Errorf("Expected status SUCCESS, got: %s", first.Status)
@@ -60,14 +60,127 @@ func TestParseCSVToTransactions_InvalidHeaders(t *testing.T) {
 }
 
 func TestParseCSVToTransactions_MissingHeaders(t *testing.T) {
+	csvData := `timestamp,name,type,status,description
+1704067200,John Doe,CREDIT,SUCCESS,Initial deposit`
+
+	reader := strings.NewReader(csvData)
+	_, err := ParseCSVToTransactions(reader)
+
+	if err == nil {
+		t.Fatal("Expected error for missing headers, got none")
+	}
+
+	if !strings.Contains(err.Error(), "missing required columns: amount") {
+		t.Errorf("Expected error message about missing amount column, got: %v", err)
+	}
+}
+
+func TestParseCSVToTransactions_OptionalDescription(t *testing.T) {
 	csvData := `timestamp,name,type,amount,status
 1704067200,John Doe,CREDIT,1000000,SUCCESS`
 
+	reader := strings.NewReader(csvData)
+	transactions, err := ParseCSVToTransactions(reader)
+
+	if err != nil {
+		t.Fatalf("Expected no error without description column, got: %v", err)
+	}
+
+	if len(transactions) != 1 {
+		t.Fatalf("Expected 1 transaction, got: %d", len(transactions))
+	}
+
+	if transactions[0].Description != "" {
+		t.Errorf("Expected empty description, got: '%s'", transactions[0].Description)
+	}
+}
+
+func TestParseCSVToTransactions_ReorderedAndExtraColumns(t *testing.T) {
+	csvData := `status,amount,branch,Name,TYPE,timestamp,description
+SUCCESS,1000000,Jakarta,John Doe,CREDIT,1704067200,Initial deposit`
+
+	reader := strings.NewReader(csvData)
+	transactions, err := ParseCSVToTransactions(reader)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(transactions) != 1 {
+		t.Fatalf("Expected 1 transaction, got: %d", len(transactions))
+	}
+
+	transaction := transactions[0]
+	if transaction.Name != "john doe" {
+		t.Errorf("Expected name 'john doe', got: %s", transaction.Name)
+	}
+	if transaction.Amount != 100000000 {
+		t.Errorf("Expected amount 100000000, got: %d", transaction.Amount)
+	}
+	if transaction.Description != "Initial deposit" {
+		t.Errorf("Expected description 'Initial deposit', got: %s", transaction.Description)
+	}
+}
+
+func TestParseCSVToTransactions_DefaultAliases(t *testing.T) {
+	csvData := `date,counterparty,direction,amount,status
+1704067200,John Doe,DEBIT,250000,PENDING`
+
+	reader := strings.NewReader(csvData)
+	transactions, err := ParseCSVToTransactions(reader)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(transactions) != 1 {
+		t.Fatalf("Expected 1 transaction, got: %d", len(transactions))
+	}
+
+	if transactions[0].Name != "john doe" || transactions[0].Type != domain.TransactionTypeDebit {
+		t.Errorf("Expected aliased columns to be mapped, got: %+v", transactions[0])
+	}
+}
+
+func TestParseCSV_CustomAliases(t *testing.T) {
+	csvData := `tanggal,nama,jenis,nominal,status
+1704067200,John Doe,CREDIT,1000000,SUCCESS`
+
+	aliases, err := ParseAliases("tanggal=timestamp, nama=name, jenis=type, nominal=amount")
+	if err != nil {
+		t.Fatalf("Expected no error parsing aliases, got: %v", err)
+	}
+
+	result, err := ParseCSV(strings.NewReader(csvData), Options{Aliases: aliases})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(result.Transactions) != 1 || result.Transactions[0].Amount != 100000000 {
+		t.Errorf("Expected custom aliases to be mapped, got: %+v", result.Transactions)
+	}
+}
+
+func TestParseCSVToTransactions_DuplicateColumn(t *testing.T) {
+	csvData := `timestamp,date,name,type,amount,status
+1704067200,1704067200,John Doe,CREDIT,1000000,SUCCESS`
+
 	reader := strings.NewReader(csvData)
 	_, err := ParseCSVToTransactions(reader)
 
 	if err == nil {
-		t.Fatal("Expected error for missing headers, got none")
+		t.Fatal("Expected error for duplicate column, got none")
+	}
+
+	if !strings.Contains(err.Error(), "duplicate column 'timestamp'") {
+		t.Errorf("Expected error message about duplicate column, got: %v", err)
+	}
+}
+
+func TestParseAliases_UnknownColumn(t *testing.T) {
+	if _, err := ParseAliases("foo=bar"); err == nil {
+		t.Error("Expected error for alias to unknown column, got none")
 	}
 }
 
@@ -166,8 +279,8 @@ func TestParseCSVToTransactions_WithWhitespace(t *testing.T) {
 	}
 
 	transaction := transactions[0]
-	if transaction.Name != "John Doe" {
-		t.Errorf("Expected trimmed name 'John Doe', got: '%s'", transaction.Name)
+	if transaction.Name != "john doe" {
+		t.Errorf("Expected trimmed and folded name 'john doe', got: '%s'", transaction.Name)
 	}
 	if transaction.Description != "Initial deposit" {
 		t.Errorf("Expected trimmed description 'Initial deposit', got: '%s'", transaction.Description)
@@ -189,3 +302,272 @@ func TestParseCSVToTransactions_WrongColumnCount(t *testing.T) {
 		t.Errorf("Expected error message about wrong number of fields, got: %v", err)
 	}
 }
+
+func TestParseCSV_LinesWithMultilineField(t *testing.T) {
+	csvData := "timestamp,name,type,amount,status,description\n" +
+		"1704067200,John Doe,CREDIT,1000,SUCCESS,\"first line\nsecond line\"\n" +
+		"1704153600,Jane Smith,DEBIT,not_a_number,FAILED,x\n" +
+		"1704240000,Bob,CREDIT,500,SUCCESS,y\n"
+
+	result, err := ParseCSV(strings.NewReader(csvData), Options{Mode: ModeLenient})
+	if err != nil {
+		t.Fatalf("Expected no error in lenient mode, got: %v", err)
+	}
+
+	if len(result.Lines) != 2 || result.Lines[0] != 2 || result.Lines[1] != 5 {
+		t.Errorf("Expected valid transactions on lines 2 and 5, got: %v", result.Lines)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Line != 4 {
+		t.Errorf("Expected the invalid amount on line 4, got: %+v", result.Errors)
+	}
+}
+
+func TestParseCSV_LenientCollectsAllRowErrors(t *testing.T) {
+	csvData := `timestamp,name,type,amount,status,description
+1704067200,John Doe,CREDIT,1000000,SUCCESS,Initial deposit
+invalid_timestamp,Jane Smith,DEBIT,250000,FAILED,Failed payment
+1704153600,Jane Smith,DEBIT,not_a_number,FAILED,Failed payment
+1704240000,Bob,CREDIT,500000
+1704326400,Alice,DEBIT,100000,PENDING,Pending payment`
+
+	result, err := ParseCSV(strings.NewReader(csvData), Options{Mode: ModeLenient})
+
+	if err != nil {
+		t.Fatalf("Expected no error in lenient mode, got: %v", err)
+	}
+
+	if len(result.Transactions) != 2 {
+		t.Fatalf("Expected 2 valid transactions, got: %d", len(result.Transactions))
+	}
+
+	if len(result.Lines) != 2 || result.Lines[0] != 2 || result.Lines[1] != 6 {
+		t.Errorf("Expected valid transactions on lines 2 and 6, got: %v", result.Lines)
+	}
+
+	if len(result.Errors) != 3 {
+		t.Fatalf("Expected 3 row errors, got: %d", len(result.Errors))
+	}
+
+	timestampErr := result.Errors[0]
+	if timestampErr.Line != 3 || timestampErr.Column != "timestamp" || timestampErr.Value != "invalid_timestamp" {
+		t.Errorf("Unexpected timestamp error: %+v", timestampErr)
+	}
+
+	amountErr := result.Errors[1]
+	if amountErr.Line != 4 || amountErr.Column != "amount" || !strings.Contains(amountErr.Reason, "invalid amount") {
+		t.Errorf("Unexpected amount error: %+v", amountErr)
+	}
+
+	fieldCountErr := result.Errors[2]
+	if fieldCountErr.Line != 5 || !strings.Contains(fieldCountErr.Reason, "wrong number of fields") {
+		t.Errorf("Unexpected field count error: %+v", fieldCountErr)
+	}
+}
+
+func TestParseCSV_StrictStopsAtFirstRowError(t *testing.T) {
+	csvData := `timestamp,name,type,amount,status,description
+invalid_timestamp,John Doe,CREDIT,1000000,SUCCESS,Initial deposit
+1704153600,Jane Smith,DEBIT,not_a_number,FAILED,Failed payment`
+
+	_, err := ParseCSV(strings.NewReader(csvData), Options{Mode: ModeStrict})
+
+	if err == nil {
+		t.Fatal("Expected error in strict mode, got none")
+	}
+
+	if !strings.Contains(err.Error(), "line 2: invalid timestamp") {
+		t.Errorf("Expected error for line 2 timestamp, got: %v", err)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	if mode, err := ParseMode(""); err != nil || mode != ModeStrict {
+		t.Errorf("Expected empty mode to default to strict, got: %s, %v", mode, err)
+	}
+
+	if mode, err := ParseMode("Lenient"); err != nil || mode != ModeLenient {
+		t.Errorf("Expected lenient mode, got: %s, %v", mode, err)
+	}
+
+	if _, err := ParseMode("sloppy"); err == nil {
+		t.Error("Expected error for unknown mode, got none")
+	}
+}
+
+func TestParseCSVToTransactions_CurrencyColumn(t *testing.T) {
+	csvData := `timestamp,name,type,amount,status,currency
+1704067200,John Doe,CREDIT,1000000,SUCCESS,
+1704067200,Jane Smith,CREDIT,12.50,SUCCESS,usd
+1704067200,Bob,DEBIT,7,SUCCESS,SGD`
+
+	reader := strings.NewReader(csvData)
+	transactions, err := ParseCSVToTransactions(reader)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if transactions[0].Currency != domain.DefaultCurrency {
+		t.Errorf("Expected empty currency to default to %s, got: %s", domain.DefaultCurrency, transactions[0].Currency)
+	}
+	if transactions[1].Currency != "USD" || transactions[1].Amount != 1250 {
+		t.Errorf("Expected USD 1250, got: %s %d", transactions[1].Currency, transactions[1].Amount)
+	}
+	if transactions[2].Currency != "SGD" || transactions[2].Amount != 700 {
+		t.Errorf("Expected SGD 700, got: %s %d", transactions[2].Currency, transactions[2].Amount)
+	}
+}
+
+func TestParseCSVToTransactions_InvalidCurrency(t *testing.T) {
+	csvData := `timestamp,name,type,amount,status,currency
+1704067200,John Doe,CREDIT,1000000,SUCCESS,RUPIAH`
+
+	reader := strings.NewReader(csvData)
+	_, err := ParseCSVToTransactions(reader)
+
+	if err == nil {
+		t.Fatal("Expected error for invalid currency, got none")
+	}
+
+	if !strings.Contains(err.Error(), "invalid currency") {
+		t.Errorf("Expected error message about invalid currency, got: %v", err)
+	}
+}
+
+func TestParseCSVToTransactions_DeterministicIDs(t *testing.T) {
+	csvData := `timestamp,name,type,amount,status,description,reference
+1704067200,John Doe,CREDIT,1000000,SUCCESS,Initial deposit,
+1704067200,John Doe,CREDIT,1000000,SUCCESS,Initial deposit,TRX-001
+1704067200,John Doe,CREDIT,1000000,PENDING,Initial deposit,`
+
+	first, err := ParseCSVToTransactions(strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	second, err := ParseCSVToTransactions(strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	for i := range first {
+		if first[i].ID != second[i].ID {
+			t.Errorf("Expected row %d to get the same ID on re-import", i+1)
+		}
+	}
+
+	if first[0].ID == first[1].ID {
+		t.Error("Expected referenced row to get an ID from its reference")
+	}
+	if first[1].ID != domain.ReferenceTransactionID("TRX-001") || first[1].Reference != "TRX-001" {
+		t.Errorf("Expected ID derived from reference TRX-001, got: %s", first[1].ID)
+	}
+
+	// Status is not part of the content identity, only of the fingerprint.
+	if first[0].ID != first[2].ID {
+		t.Error("Expected rows differing only in status to share an ID")
+	}
+	if first[0].Fingerprint() == first[2].Fingerprint() {
+		t.Error("Expected rows differing in status to have different fingerprints")
+	}
+}
+
+func TestParseCSVToTransactions_NormalizesNames(t *testing.T) {
+	csvData := "timestamp,name,type,amount,status,description\n" +
+		"1704067200,\"  John   Doe \",CREDIT,1000,SUCCESS,a\n" +
+		"1704067201,\"Ｊｏｈｎ\tDoe\",CREDIT,1000,SUCCESS,b\n"
+
+	transactions, err := ParseCSVToTransactions(strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	for _, transaction := range transactions {
+		if transaction.Name != "john doe" {
+			t.Errorf("Expected name 'john doe', got '%s'", transaction.Name)
+		}
+	}
+	if domain.NormalizeName("JOHN  DOE") != transactions[0].Name {
+		t.Error("Expected names differing only in case and spacing to be stored the same way")
+	}
+}
+
+func TestParseCSV_NameAliases(t *testing.T) {
+	aliases, err := LoadNameAliases(strings.NewReader("alias,name\nJ. Doe,John Doe\nPT  ABC Tbk,PT ABC\n"))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	csvData := `timestamp,name,type,amount,status,description
+1704067200,j. doe,CREDIT,1000,SUCCESS,a
+1704067201,PT ABC TBK,DEBIT,500,SUCCESS,b
+1704067202,Someone Else,DEBIT,500,SUCCESS,c`
+
+	result, err := ParseCSV(strings.NewReader(csvData), Options{NameAliases: aliases})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := []string{"john doe", "pt abc", "someone else"}
+	for i, transaction := range result.Transactions {
+		if transaction.Name != expected[i] {
+			t.Errorf("Row %d: expected name '%s', got '%s'", i+1, expected[i], transaction.Name)
+		}
+	}
+}
+
+func TestParseCSV_NameAliasKeepsTransactionID(t *testing.T) {
+	csvData := `timestamp,name,type,amount,status,description
+1704067200,j. doe,CREDIT,1000,SUCCESS,a`
+
+	before, err := ParseCSV(strings.NewReader(csvData), Options{})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	aliases, err := LoadNameAliases(strings.NewReader("alias,name\nJ. Doe,John Doe\n"))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	after, err := ParseCSV(strings.NewReader(csvData), Options{NameAliases: aliases})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	original, aliased := before.Transactions[0], after.Transactions[0]
+	if aliased.Name != "john doe" || aliased.AliasedFrom != "j. doe" {
+		t.Errorf("Expected the alias to replace the name, got %+v", aliased)
+	}
+	if aliased.ID != original.ID || aliased.Fingerprint() != original.Fingerprint() {
+		t.Error("Expected adding an alias to keep re-imports of the row duplicates")
+	}
+}
+
+func TestLoadNameAliases_InvalidHeader(t *testing.T) {
+	if _, err := LoadNameAliases(strings.NewReader("from,to\na,b\n")); err == nil {
+		t.Error("Expected an error for a header other than 'alias,name'")
+	}
+}
+
+func TestParseCSVToTransactions_ReversesColumn(t *testing.T) {
+	originalID := "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	csvData := `timestamp,name,type,amount,status,reference,reverses
+1704067200,Shop,DEBIT,1000,SUCCESS,INV-1,
+1704153600,Shop,CREDIT,1000,SUCCESS,RF-1,INV-1
+1704153601,Shop,CREDIT,1000,SUCCESS,RF-2,` + originalID
+
+	transactions, err := ParseCSVToTransactions(strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if transactions[0].IsReversal() {
+		t.Error("Expected the first row not to be a reversal")
+	}
+	if transactions[1].ReversalOf != transactions[0].ID {
+		t.Errorf("Expected a reference to resolve to the original ID %s, got %s", transactions[0].ID, transactions[1].ReversalOf)
+	}
+	if transactions[2].ReversalOf.String() != originalID {
+		t.Errorf("Expected an ID to be used as is, got %s", transactions[2].ReversalOf)
+	}
+}
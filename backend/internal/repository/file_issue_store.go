@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"flip-test/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const issuesFileName = "issues.json"
+
+var _ IssueStore = (*FileIssueStore)(nil)
+
+// FileIssueStore keeps issues in a file in dir, rewritten on every save.
+type FileIssueStore struct {
+	issues *jsonFileStore[uuid.UUID, domain.Issue]
+}
+
+func NewFileIssueStore(dir string) (*FileIssueStore, error) {
+	issues, err := newJSONFileStore(dir, issuesFileName, func(issue domain.Issue) uuid.UUID {
+		return issue.TransactionID
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileIssueStore{issues: issues}, nil
+}
+
+func (fs *FileIssueStore) SaveIssue(issue domain.Issue) error {
+	return fs.issues.save(issue)
+}
+
+func (fs *FileIssueStore) GetIssue(transactionID uuid.UUID) (domain.Issue, bool) {
+	return fs.issues.get(transactionID)
+}
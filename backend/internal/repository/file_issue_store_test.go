@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"flip-test/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestFileIssueStore_KeepsIssuesAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileIssueStore(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	issue := domain.Issue{
+		TransactionID: uuid.New(),
+		State:         domain.IssueStateInvestigating,
+		Assignee:      "ops",
+		Notes:         []domain.IssueNote{{Author: "ops", Text: "asked the bank", CreatedAt: now}},
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := store.SaveIssue(issue); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	issue.State = domain.IssueStateResolved
+	if err := store.SaveIssue(issue); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	reopened, err := NewFileIssueStore(dir)
+	if err != nil {
+		t.Fatalf("Expected no error reopening, got: %v", err)
+	}
+
+	stored, ok := reopened.GetIssue(issue.TransactionID)
+	if !ok || stored.State != domain.IssueStateResolved || stored.Assignee != "ops" {
+		t.Fatalf("Expected the latest issue to survive a restart, got %+v", stored)
+	}
+	if len(stored.Notes) != 1 || stored.Notes[0].Text != "asked the bank" {
+		t.Errorf("Expected the notes to survive a restart, got %+v", stored.Notes)
+	}
+}
@@ -0,0 +1,401 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flip-test/internal/domain"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const (
+	logFileName      = "transactions.v2.log"
+	snapshotFileName = "transactions.v2.snapshot.json"
+	// The legacy files were written while IDR amounts were stored in whole
+	// rupiah. They are migrated once into the files above, with IDR amounts
+	// converted to sen.
+	legacyLogFileName      = "transactions.log"
+	legacySnapshotFileName = "transactions.snapshot.json"
+)
+
+const (
+	opSave           = "save"
+	opDeleteByUpload = "delete_upload"
+	opUpdate         = "update"
+)
+
+// logEntry is one line of the append-only log. Entries record the requested
+// mutation rather than its effect; replaying them in order through the
+// in-memory store reproduces the same state because every mutation is
+// deterministic and idempotent.
+type logEntry struct {
+	Op           string               `json:"op"`
+	Transactions []domain.Transaction `json:"transactions,omitempty"`
+	UploadID     uuid.UUID            `json:"upload_id,omitempty"`
+}
+
+var _ TransactionStore = (*FileTransactionStore)(nil)
+
+// FileTransactionStore keeps the working set in a TransactionRepository and
+// makes it durable with an append-only log in dir. Every snapshotEvery log
+// entries the full state is written to a snapshot and the log is truncated.
+type FileTransactionStore struct {
+	memory        *TransactionRepository
+	dir           string
+	logFile       *os.File
+	snapshotEvery int
+	entries       int
+	mutex         sync.Mutex
+}
+
+// NewFileTransactionStore loads the latest snapshot in dir, replays the log
+// written after it and opens the log for appending.
+func NewFileTransactionStore(dir string, snapshotEvery int) (*FileTransactionStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	fs := &FileTransactionStore{
+		memory:        NewTransactionRepository(),
+		dir:           dir,
+		snapshotEvery: snapshotEvery,
+	}
+
+	migrate := !fs.exists(snapshotFileName) && !fs.exists(logFileName) &&
+		(fs.exists(legacySnapshotFileName) || fs.exists(legacyLogFileName))
+	snapshotName, logName := snapshotFileName, logFileName
+	if migrate {
+		snapshotName, logName = legacySnapshotFileName, legacyLogFileName
+	}
+
+	if err := fs.loadSnapshot(snapshotName); err != nil {
+		return nil, err
+	}
+	if err := fs.replayLog(logName); err != nil {
+		return nil, err
+	}
+
+	logFile, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transaction log: %w", err)
+	}
+	fs.logFile = logFile
+
+	if migrate {
+		if err := fs.migrateLegacy(); err != nil {
+			logFile.Close()
+			return nil, err
+		}
+	}
+
+	return fs, nil
+}
+
+// migrateLegacy converts the IDR amounts loaded from the legacy files from
+// whole rupiah to sen and writes them as the first snapshot. The legacy
+// files stay authoritative until that snapshot is renamed into place, so a
+// crash during the migration only means it runs again.
+func (fs *FileTransactionStore) migrateLegacy() error {
+	transactions := fs.memory.GetTransactions()
+	for i := range transactions {
+		if transactions[i].EffectiveCurrency() == "IDR" {
+			transactions[i].Amount *= 100
+		}
+	}
+
+	fs.memory = NewTransactionRepository()
+	if _, err := fs.memory.SaveTransactions(transactions); err != nil {
+		return err
+	}
+	if err := fs.snapshot(); err != nil {
+		return fmt.Errorf("failed to migrate legacy transaction files: %w", err)
+	}
+
+	for _, name := range []string{legacySnapshotFileName, legacyLogFileName} {
+		if err := os.Remove(filepath.Join(fs.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Failed to remove migrated %s: %v", name, err)
+		}
+	}
+	log.Printf("Migrated %d transactions from the legacy transaction files", len(transactions))
+	return nil
+}
+
+func (fs *FileTransactionStore) exists(name string) bool {
+	_, err := os.Stat(filepath.Join(fs.dir, name))
+	return err == nil
+}
+
+func (fs *FileTransactionStore) GetTransactions() []domain.Transaction {
+	return fs.memory.GetTransactions()
+}
+
+func (fs *FileTransactionStore) GetTransaction(id uuid.UUID) (domain.Transaction, bool) {
+	return fs.memory.GetTransaction(id)
+}
+
+func (fs *FileTransactionStore) GetTransactionsByUpload(uploadID uuid.UUID) []domain.Transaction {
+	return fs.memory.GetTransactionsByUpload(uploadID)
+}
+
+func (fs *FileTransactionStore) GetTransactionsByName(name string) []domain.Transaction {
+	return fs.memory.GetTransactionsByName(name)
+}
+
+func (fs *FileTransactionStore) ListTransactions(query domain.TransactionQuery) []domain.Transaction {
+	return fs.memory.ListTransactions(query)
+}
+
+func (fs *FileTransactionStore) GetBalanceSummary() domain.BalanceSummary {
+	return fs.memory.GetBalanceSummary()
+}
+
+func (fs *FileTransactionStore) SaveTransactions(transactions []domain.Transaction) (domain.SaveResult, error) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if err := fs.appendLog(logEntry{Op: opSave, Transactions: transactions}); err != nil {
+		return domain.SaveResult{}, err
+	}
+
+	result, err := fs.memory.SaveTransactions(transactions)
+	if err != nil {
+		return domain.SaveResult{}, err
+	}
+
+	fs.snapshotIfDue()
+	return result, nil
+}
+
+// UpdateTransaction runs update before logging so the log holds the
+// resulting transaction, which replays without the update function. Writes
+// are serialized by fs.mutex, so the stored copy cannot change in between.
+func (fs *FileTransactionStore) UpdateTransaction(id uuid.UUID, update func(*domain.Transaction) error) (domain.Transaction, error) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	existing, ok := fs.memory.GetTransaction(id)
+	if !ok {
+		return domain.Transaction{}, ErrTransactionNotFound
+	}
+
+	updated := existing
+	updated.StatusHistory = slices.Clone(existing.StatusHistory)
+	if err := update(&updated); err != nil {
+		return domain.Transaction{}, err
+	}
+	updated.ID = id
+
+	if err := fs.appendLog(logEntry{Op: opUpdate, Transactions: []domain.Transaction{updated}}); err != nil {
+		return domain.Transaction{}, err
+	}
+
+	if _, err := fs.memory.UpdateTransaction(id, replaceWith(updated)); err != nil {
+		return domain.Transaction{}, err
+	}
+
+	fs.snapshotIfDue()
+	return updated, nil
+}
+
+func (fs *FileTransactionStore) DeleteTransactionsByUpload(uploadID uuid.UUID) ([]domain.Transaction, error) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	// Every write goes through fs.mutex, so nothing can claim a reversal
+	// between this check and the delete.
+	if err := fs.memory.checkUploadDeletable(uploadID); err != nil {
+		return nil, err
+	}
+	if err := fs.appendLog(logEntry{Op: opDeleteByUpload, UploadID: uploadID}); err != nil {
+		return nil, err
+	}
+
+	removed, err := fs.memory.DeleteTransactionsByUpload(uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	fs.snapshotIfDue()
+	return removed, nil
+}
+
+func (fs *FileTransactionStore) Close() error {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	return fs.logFile.Close()
+}
+
+func (fs *FileTransactionStore) appendLog(entry logEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode log entry: %w", err)
+	}
+
+	if _, err := fs.logFile.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write transaction log: %w", err)
+	}
+	if err := fs.logFile.Sync(); err != nil {
+		return fmt.Errorf("failed to sync transaction log: %w", err)
+	}
+
+	fs.entries++
+	return nil
+}
+
+func (fs *FileTransactionStore) apply(entry logEntry) error {
+	switch entry.Op {
+	case opSave:
+		_, err := fs.memory.SaveTransactions(entry.Transactions)
+		return err
+	case opDeleteByUpload:
+		_, err := fs.memory.DeleteTransactionsByUpload(entry.UploadID)
+		return err
+	case opUpdate:
+		for _, transaction := range entry.Transactions {
+			// The transaction is missing when a snapshot already holds the
+			// effect of a later delete_upload; that delete removes it again
+			// anyway, so the update has nothing left to do.
+			_, err := fs.memory.UpdateTransaction(transaction.ID, replaceWith(transaction))
+			if err != nil && !errors.Is(err, ErrTransactionNotFound) {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown log operation '%s'", entry.Op)
+	}
+}
+
+// snapshotIfDue runs after a write has reached the log, so a failed snapshot
+// does not fail the write. It is logged and retried on the next write, as
+// the entry count only resets once a snapshot succeeds.
+func (fs *FileTransactionStore) snapshotIfDue() {
+	if fs.snapshotEvery <= 0 || fs.entries < fs.snapshotEvery {
+		return
+	}
+
+	if err := fs.snapshot(); err != nil {
+		log.Printf("Failed to snapshot transactions: %v", err)
+	}
+}
+
+// snapshot writes the full state next to the log and then truncates the log.
+// A crash between the two steps only means some entries are replayed on top
+// of a snapshot that already contains them, which is harmless.
+func (fs *FileTransactionStore) snapshot() error {
+	data, err := json.Marshal(fs.memory.GetTransactions())
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	path := filepath.Join(fs.dir, snapshotFileName)
+	tmpPath := path + ".tmp"
+	if err := writeFileSync(tmpPath, data); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+
+	if err := fs.logFile.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate transaction log: %w", err)
+	}
+	fs.entries = 0
+
+	log.Printf("Wrote transaction snapshot to %s", path)
+	return nil
+}
+
+func (fs *FileTransactionStore) loadSnapshot(name string) error {
+	data, err := os.ReadFile(filepath.Join(fs.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var transactions []domain.Transaction
+	if err := json.Unmarshal(data, &transactions); err != nil {
+		return fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+
+	_, err = fs.memory.SaveTransactions(transactions)
+	return err
+}
+
+func (fs *FileTransactionStore) replayLog(name string) error {
+	path := filepath.Join(fs.dir, name)
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open transaction log: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for lineNum := 1; ; lineNum++ {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(bytes.TrimSpace(line)) > 0 {
+				// The process stopped in the middle of an append; the
+				// mutation was never acknowledged, so drop it.
+				log.Printf("Discarding incomplete entry at the end of %s", path)
+				return os.Truncate(path, offset)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read transaction log: %w", err)
+		}
+
+		var entry logEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("transaction log line %d: %w", lineNum, err)
+		}
+		if err := fs.apply(entry); err != nil {
+			return fmt.Errorf("transaction log line %d: %w", lineNum, err)
+		}
+
+		offset += int64(len(line))
+		fs.entries++
+	}
+}
+
+func replaceWith(transaction domain.Transaction) func(*domain.Transaction) error {
+	return func(stored *domain.Transaction) error {
+		*stored = transaction
+		return nil
+	}
+}
+
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"flip-test/internal/domain"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func newStoredTransaction(name string, amount int64, uploadID uuid.UUID) domain.Transaction {
+	return domain.Transaction{
+		ID:       uuid.New(),
+		Name:     name,
+		Type:     domain.TransactionTypeCredit,
+		Amount:   amount,
+		Status:   domain.TransactionStatusSuccess,
+		UploadID: uploadID,
+	}
+}
+
+func TestFileTransactionStore_ReplaysLogAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	firstUpload, secondUpload := uuid.New(), uuid.New()
+
+	store, err := NewFileTransactionStore(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	kept := newStoredTransaction("Kept", 1000, firstUpload)
+	if _, err := store.SaveTransactions([]domain.Transaction{kept}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := store.SaveTransactions([]domain.Transaction{newStoredTransaction("Removed", 500, secondUpload)}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := store.DeleteTransactionsByUpload(secondUpload); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	store.Close()
+
+	reopened, err := NewFileTransactionStore(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected no error reopening, got: %v", err)
+	}
+	defer reopened.Close()
+
+	transactions := reopened.GetTransactions()
+	if len(transactions) != 1 || transactions[0].ID != kept.ID {
+		t.Fatalf("Expected only the kept transaction after replay, got %+v", transactions)
+	}
+}
+
+func TestFileTransactionStore_SnapshotTruncatesLog(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileTransactionStore(dir, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := store.SaveTransactions([]domain.Transaction{newStoredTransaction("Credit", 1000, uuid.Nil)}); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+	store.Close()
+
+	if _, err := os.Stat(filepath.Join(dir, snapshotFileName)); err != nil {
+		t.Fatalf("Expected snapshot to be written, got: %v", err)
+	}
+
+	logData, err := os.ReadFile(filepath.Join(dir, logFileName))
+	if err != nil {
+		t.Fatalf("Expected log to exist, got: %v", err)
+	}
+	if lines := countLines(logData); lines != 1 {
+		t.Errorf("Expected 1 log entry after snapshot, got %d", lines)
+	}
+
+	reopened, err := NewFileTransactionStore(dir, 2)
+	if err != nil {
+		t.Fatalf("Expected no error reopening, got: %v", err)
+	}
+	defer reopened.Close()
+
+	if count := len(reopened.GetTransactions()); count != 3 {
+		t.Errorf("Expected 3 transactions from snapshot and log, got %d", count)
+	}
+}
+
+func TestFileTransactionStore_DiscardsIncompleteLastEntry(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileTransactionStore(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := store.SaveTransactions([]domain.Transaction{newStoredTransaction("Credit", 1000, uuid.Nil)}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	store.Close()
+
+	logFile, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logFile.WriteString(`{"op":"save","transactions":[{"id":`)
+	logFile.Close()
+
+	reopened, err := NewFileTransactionStore(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected incomplete entry to be discarded, got: %v", err)
+	}
+	defer reopened.Close()
+
+	if count := len(reopened.GetTransactions()); count != 1 {
+		t.Errorf("Expected 1 transaction, got %d", count)
+	}
+}
+
+func countLines(data []byte) int {
+	count := 0
+	for _, b := range data {
+		if b == '\n' {
+			count++
+		}
+	}
+	return count
+}
+
+func TestFileTransactionStore_ReplaysUpdates(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileTransactionStore(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	transaction := newStoredTransaction("Pending", 1000, uuid.New())
+	transaction.Status = domain.TransactionStatusPending
+	if _, err := store.SaveTransactions([]domain.Transaction{transaction}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	_, err = store.UpdateTransaction(transaction.ID, func(stored *domain.Transaction) error {
+		stored.Status = domain.TransactionStatusSuccess
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	store.Close()
+
+	reopened, err := NewFileTransactionStore(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	defer reopened.Close()
+
+	stored, ok := reopened.GetTransaction(transaction.ID)
+	if !ok || stored.Status != domain.TransactionStatusSuccess {
+		t.Errorf("Expected the update to survive a restart, got %+v", stored)
+	}
+	if totals := reopened.GetBalanceSummary()[domain.DefaultCurrency]; totals[domain.TransactionStatusSuccess].Net != 1000 {
+		t.Errorf("Expected the replayed update in the totals, got %+v", totals)
+	}
+}
+
+func TestFileTransactionStore_ReplaysLogOverNewerSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	uploadID := uuid.New()
+
+	store, err := NewFileTransactionStore(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	transaction := newStoredTransaction("Pending", 1000, uploadID)
+	transaction.Status = domain.TransactionStatusPending
+	if _, err := store.SaveTransactions([]domain.Transaction{transaction}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := store.snapshot(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	_, err = store.UpdateTransaction(transaction.ID, func(stored *domain.Transaction) error {
+		stored.Status = domain.TransactionStatusFailed
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := store.DeleteTransactionsByUpload(uploadID); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// Simulate a crash after the next snapshot is renamed into place but
+	// before the log is truncated: the log still holds the update of a
+	// transaction the snapshot no longer contains.
+	logPath := filepath.Join(dir, logFileName)
+	logData, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.snapshot(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	store.Close()
+	if err := os.WriteFile(logPath, logData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileTransactionStore(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected the log to replay over the snapshot, got: %v", err)
+	}
+	defer reopened.Close()
+
+	if count := len(reopened.GetTransactions()); count != 0 {
+		t.Errorf("Expected no transactions, got %d", count)
+	}
+}
+
+func TestFileTransactionStore_MigratesLegacyRupiahAmounts(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileTransactionStore(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	rupiah := newStoredTransaction("Rupiah", 1000, uuid.Nil)
+	dollar := newStoredTransaction("Dollar", 1050, uuid.Nil)
+	dollar.Currency = "USD"
+	if _, err := store.SaveTransactions([]domain.Transaction{rupiah}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := store.snapshot(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := store.SaveTransactions([]domain.Transaction{dollar}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	store.Close()
+
+	// Move the files to where a store holding whole rupiah wrote them.
+	if err := os.Rename(filepath.Join(dir, snapshotFileName), filepath.Join(dir, legacySnapshotFileName)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, logFileName), filepath.Join(dir, legacyLogFileName)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		reopened, err := NewFileTransactionStore(dir, 0)
+		if err != nil {
+			t.Fatalf("Expected no error reopening, got: %v", err)
+		}
+
+		if stored, _ := reopened.GetTransaction(rupiah.ID); stored.Amount != 100000 {
+			t.Errorf("Expected the rupiah amount in sen, got %d", stored.Amount)
+		}
+		if stored, _ := reopened.GetTransaction(dollar.ID); stored.Amount != 1050 {
+			t.Errorf("Expected the dollar amount unchanged, got %d", stored.Amount)
+		}
+		reopened.Close()
+	}
+
+	for _, name := range []string{legacySnapshotFileName, legacyLogFileName} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got: %v", name, err)
+		}
+	}
+}
+
+func TestFileTransactionStore_SnapshotFailureKeepsWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileTransactionStore(dir, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	defer store.Close()
+
+	// A directory at the temporary snapshot path makes the snapshot write fail.
+	tmpPath := filepath.Join(dir, snapshotFileName+".tmp")
+	if err := os.Mkdir(tmpPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	transaction := newStoredTransaction("Credit", 1000, uuid.Nil)
+	if _, err := store.SaveTransactions([]domain.Transaction{transaction}); err != nil {
+		t.Fatalf("Expected the logged write to succeed, got: %v", err)
+	}
+	if _, ok := store.GetTransaction(transaction.ID); !ok {
+		t.Fatal("Expected the transaction to be stored")
+	}
+
+	if err := os.Remove(tmpPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.SaveTransactions([]domain.Transaction{newStoredTransaction("Debit", 500, uuid.Nil)}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, snapshotFileName)); err != nil {
+		t.Errorf("Expected the snapshot to be retried on the next write, got: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"flip-test/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const uploadsFileName = "uploads.json"
+
+var _ UploadStore = (*FileUploadStore)(nil)
+
+// FileUploadStore keeps uploads in a file in dir, rewritten on every save.
+type FileUploadStore struct {
+	uploads *jsonFileStore[uuid.UUID, domain.Upload]
+}
+
+func NewFileUploadStore(dir string) (*FileUploadStore, error) {
+	uploads, err := newJSONFileStore(dir, uploadsFileName, func(upload domain.Upload) uuid.UUID {
+		return upload.ID
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileUploadStore{uploads: uploads}, nil
+}
+
+func (fs *FileUploadStore) SaveUpload(upload domain.Upload) error {
+	return fs.uploads.save(upload)
+}
+
+func (fs *FileUploadStore) GetUpload(id uuid.UUID) (domain.Upload, bool) {
+	return fs.uploads.get(id)
+}
+
+// GetUploads returns every upload, newest first.
+func (fs *FileUploadStore) GetUploads() []domain.Upload {
+	uploads := fs.uploads.all()
+	sortNewestFirst(uploads)
+	return uploads
+}
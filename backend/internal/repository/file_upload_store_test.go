@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"flip-test/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestFileUploadStore_KeepsUploadsAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileUploadStore(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	upload := domain.Upload{ID: uuid.New(), Filename: "statement.csv", UploadedAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+	if err := store.SaveUpload(upload); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	upload.Rollback = &domain.UploadRollback{RolledBackBy: "ops", Reason: "wrong file", TransactionsRemoved: 2}
+	if err := store.SaveUpload(upload); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	reopened, err := NewFileUploadStore(dir)
+	if err != nil {
+		t.Fatalf("Expected no error reopening, got: %v", err)
+	}
+
+	stored, ok := reopened.GetUpload(upload.ID)
+	if !ok || stored.Filename != "statement.csv" {
+		t.Fatalf("Expected the upload to survive a restart, got %+v", stored)
+	}
+	if stored.Rollback == nil || stored.Rollback.Reason != "wrong file" {
+		t.Errorf("Expected the rollback audit to survive a restart, got %+v", stored.Rollback)
+	}
+	if uploads := reopened.GetUploads(); len(uploads) != 1 {
+		t.Errorf("Expected 1 upload, got %d", len(uploads))
+	}
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"flip-test/internal/domain"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var _ IssueStore = (*IssueRepository)(nil)
+
+type IssueRepository struct {
+	store map[uuid.UUID]domain.Issue
+	mutex sync.RWMutex
+}
+
+func NewIssueRepository() *IssueRepository {
+	return &IssueRepository{store: make(map[uuid.UUID]domain.Issue)}
+}
+
+func (ir *IssueRepository) SaveIssue(issue domain.Issue) error {
+	ir.mutex.Lock()
+	defer ir.mutex.Unlock()
+
+	ir.store[issue.TransactionID] = issue
+	return nil
+}
+
+func (ir *IssueRepository) GetIssue(transactionID uuid.UUID) (domain.Issue, bool) {
+	ir.mutex.RLock()
+	defer ir.mutex.RUnlock()
+
+	issue, ok := ir.store[transactionID]
+	return issue, ok
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"flip-test/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// IssueStore keeps the issue of each unsuccessful transaction. IssueRepository
+// keeps them in memory only; FileIssueStore also persists them.
+type IssueStore interface {
+	SaveIssue(issue domain.Issue) error
+	GetIssue(transactionID uuid.UUID) (domain.Issue, bool)
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// jsonFileStore keeps records by key in memory and rewrites them all to one
+// JSON file on every save. It suits records that are few and change rarely,
+// which unlike transactions need no log.
+type jsonFileStore[K comparable, V any] struct {
+	path    string
+	keyOf   func(V) K
+	records map[K]V
+	mutex   sync.RWMutex
+}
+
+func newJSONFileStore[K comparable, V any](dir string, name string, keyOf func(V) K) (*jsonFileStore[K, V], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	store := &jsonFileStore[K, V]{
+		path:    filepath.Join(dir, name),
+		keyOf:   keyOf,
+		records: make(map[K]V),
+	}
+
+	var records []V
+	if err := readJSONFile(store.path, &records); err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		store.records[keyOf(record)] = record
+	}
+
+	return store, nil
+}
+
+// save writes the file before updating memory, so a failed write leaves
+// both unchanged.
+func (s *jsonFileStore[K, V]) save(record V) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := s.keyOf(record)
+	records := make([]V, 0, len(s.records)+1)
+	for k, existing := range s.records {
+		if k != key {
+			records = append(records, existing)
+		}
+	}
+	records = append(records, record)
+
+	if err := writeJSONFile(s.path, records); err != nil {
+		return err
+	}
+	s.records[key] = record
+	return nil
+}
+
+func (s *jsonFileStore[K, V]) get(key K) (V, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	record, ok := s.records[key]
+	return record, ok
+}
+
+func (s *jsonFileStore[K, V]) all() []V {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	records := make([]V, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+	return records
+}
+
+// readJSONFile decodes the file at path into v. A missing file leaves v
+// untouched.
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeJSONFile replaces the file at path with v encoded as JSON. It writes
+// a temporary file first, so a crash leaves either the old or the new file.
+func writeJSONFile(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := writeFileSync(tmpPath, data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"bytes"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type indexEntry struct {
+	key  string
+	date time.Time
+	id   uuid.UUID
+}
+
+func (e indexEntry) compare(other indexEntry) int {
+	if result := strings.Compare(e.key, other.key); result != 0 {
+		return result
+	}
+	if result := e.date.Compare(other.date); result != 0 {
+		return result
+	}
+	return bytes.Compare(e.id[:], other.id[:])
+}
+
+// orderedIndex keeps entries sorted by key, then date, then ID, so every
+// key is a contiguous run ordered by date and a date range within a key is
+// found with two binary searches.
+type orderedIndex struct {
+	entries []indexEntry
+}
+
+// insert merges a batch into the index in O(n + m log m) instead of shifting
+// the slice once per entry.
+func (ix *orderedIndex) insert(batch []indexEntry) {
+	if len(batch) == 0 {
+		return
+	}
+
+	sort.Slice(batch, func(i, j int) bool {
+		return batch[i].compare(batch[j]) < 0
+	})
+
+	merged := make([]indexEntry, 0, len(ix.entries)+len(batch))
+	i, j := 0, 0
+	for i < len(ix.entries) && j < len(batch) {
+		if ix.entries[i].compare(batch[j]) <= 0 {
+			merged = append(merged, ix.entries[i])
+			i++
+		} else {
+			merged = append(merged, batch[j])
+			j++
+		}
+	}
+	merged = append(merged, ix.entries[i:]...)
+	merged = append(merged, batch[j:]...)
+
+	ix.entries = merged
+}
+
+func (ix *orderedIndex) remove(ids map[uuid.UUID]struct{}) {
+	if len(ids) == 0 {
+		return
+	}
+
+	kept := ix.entries[:0]
+	for _, entry := range ix.entries {
+		if _, ok := ids[entry.id]; !ok {
+			kept = append(kept, entry)
+		}
+	}
+	ix.entries = kept
+}
+
+// insertEntry adds a single entry at its position without rebuilding the index.
+func (ix *orderedIndex) insertEntry(entry indexEntry) {
+	i := ix.search(entry)
+	ix.entries = slices.Insert(ix.entries, i, entry)
+}
+
+// removeEntry deletes a single entry found by binary search.
+func (ix *orderedIndex) removeEntry(entry indexEntry) {
+	i := ix.search(entry)
+	if i < len(ix.entries) && ix.entries[i].compare(entry) == 0 {
+		ix.entries = slices.Delete(ix.entries, i, i+1)
+	}
+}
+
+func (ix *orderedIndex) search(entry indexEntry) int {
+	return sort.Search(len(ix.entries), func(i int) bool {
+		return ix.entries[i].compare(entry) >= 0
+	})
+}
+
+// rangeOf returns the entries of key with a date within [from, to]. A zero
+// from or to leaves that side unbounded. The result aliases the index and
+// must not be modified or kept after the lock is released.
+func (ix *orderedIndex) rangeOf(key string, from, to time.Time) []indexEntry {
+	start := sort.Search(len(ix.entries), func(i int) bool {
+		entry := ix.entries[i]
+		if entry.key != key {
+			return entry.key > key
+		}
+		return from.IsZero() || !entry.date.Before(from)
+	})
+	end := sort.Search(len(ix.entries), func(i int) bool {
+		entry := ix.entries[i]
+		if entry.key != key {
+			return entry.key > key
+		}
+		return !to.IsZero() && entry.date.After(to)
+	})
+
+	return ix.entries[start:end]
+}
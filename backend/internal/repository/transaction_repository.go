@@ -1,19 +1,45 @@
 package repository
 
 import (
+	"container/heap"
+	"errors"
 	"flip-test/internal/domain"
+	"fmt"
+	"slices"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrUploadReversed      = errors.New("upload has transactions reversed by another upload")
+)
+
+var _ TransactionStore = (*TransactionRepository)(nil)
+
 type TransactionRepository struct {
-	store map[uuid.UUID]domain.Transaction
-	mutex sync.RWMutex
+	store    map[uuid.UUID]domain.Transaction
+	byDate   orderedIndex
+	byStatus orderedIndex
+	byName   orderedIndex
+	// totals are updated together with store so balance reads never scan.
+	totals map[balanceKey]domain.BalanceTotals
+	mutex  sync.RWMutex
+}
+
+type balanceKey struct {
+	currency domain.Currency
+	status   domain.TransactionStatus
 }
 
 func NewTransactionRepository() *TransactionRepository {
-	return &TransactionRepository{store: make(map[uuid.UUID]domain.Transaction)}
+	return &TransactionRepository{
+		store:  make(map[uuid.UUID]domain.Transaction),
+		totals: make(map[balanceKey]domain.BalanceTotals),
+	}
 }
 
 func (tr *TransactionRepository) GetTransactions() []domain.Transaction {
@@ -28,11 +54,348 @@ func (tr *TransactionRepository) GetTransactions() []domain.Transaction {
 	return result
 }
 
-func (tr *TransactionRepository) SaveTransactions(transactions []domain.Transaction) {
+func (tr *TransactionRepository) GetTransaction(id uuid.UUID) (domain.Transaction, bool) {
+	tr.mutex.RLock()
+	defer tr.mutex.RUnlock()
+
+	transaction, ok := tr.store[id]
+	return transaction, ok
+}
+
+func (tr *TransactionRepository) GetTransactionsByUpload(uploadID uuid.UUID) []domain.Transaction {
+	tr.mutex.RLock()
+	defer tr.mutex.RUnlock()
+
+	result := make([]domain.Transaction, 0)
+	for _, transaction := range tr.store {
+		if transaction.UploadID == uploadID {
+			result = append(result, transaction)
+		}
+	}
+
+	return result
+}
+
+// GetTransactionsByName returns the transactions of one counterparty in
+// TransactionDate order. Names are matched by domain.CounterpartyKey.
+func (tr *TransactionRepository) GetTransactionsByName(name string) []domain.Transaction {
+	tr.mutex.RLock()
+	defer tr.mutex.RUnlock()
+
+	return tr.resolve(tr.byName.rangeOf(domain.CounterpartyKey(name), time.Time{}, time.Time{}))
+}
+
+// GetBalanceSummary returns a copy of the running totals. Its cost depends
+// on the number of currencies and statuses, not on the number of transactions.
+func (tr *TransactionRepository) GetBalanceSummary() domain.BalanceSummary {
+	tr.mutex.RLock()
+	defer tr.mutex.RUnlock()
+
+	summary := make(domain.BalanceSummary)
+	for key, totals := range tr.totals {
+		if summary[key.currency] == nil {
+			summary[key.currency] = make(map[domain.TransactionStatus]domain.BalanceTotals)
+		}
+		summary[key.currency][key.status] = totals
+	}
+
+	return summary
+}
+
+func (tr *TransactionRepository) ListTransactions(query domain.TransactionQuery) []domain.Transaction {
+	tr.mutex.RLock()
+	defer tr.mutex.RUnlock()
+
+	filter := query.Filter
+	if query.SortBy == domain.SortByTransactionDate && len(filter.Statuses) <= 1 {
+		index, key := &tr.byDate, ""
+		if len(filter.Statuses) == 1 {
+			index, key = &tr.byStatus, string(filter.Statuses[0])
+		}
+		return tr.scanInOrder(index.rangeOf(key, filter.From, filter.To), key, query)
+	}
+
+	// Narrow the candidates with an index when the filter allows it, then
+	// keep only the first page of what matched.
+	var candidates []indexEntry
+	switch {
+	case len(filter.Statuses) > 0:
+		for _, status := range filter.Statuses {
+			candidates = append(candidates, tr.byStatus.rangeOf(string(status), filter.From, filter.To)...)
+		}
+	case !filter.From.IsZero() || !filter.To.IsZero():
+		candidates = tr.byDate.rangeOf("", filter.From, filter.To)
+	default:
+		candidates = tr.byDate.entries
+	}
+
+	page := &transactionPage{query: query, items: make([]domain.Transaction, 0)}
+	for _, entry := range candidates {
+		transaction := tr.store[entry.id]
+		if !filter.Matches(transaction) {
+			continue
+		}
+		if query.After != nil && !query.Before(*query.After, transaction) {
+			continue
+		}
+		page.offer(transaction)
+	}
+
+	return page.sorted()
+}
+
+// transactionPage collects the first query.Limit transactions in query
+// order as a heap with the last of them at the root, so a page costs
+// O(n log Limit) rather than sorting every match. Without a limit it keeps
+// everything.
+type transactionPage struct {
+	query domain.TransactionQuery
+	items []domain.Transaction
+}
+
+func (p *transactionPage) offer(transaction domain.Transaction) {
+	switch {
+	case p.query.Limit <= 0:
+		p.items = append(p.items, transaction)
+	case len(p.items) < p.query.Limit:
+		heap.Push(p, transaction)
+	case p.query.Before(transaction, p.items[0]):
+		p.items[0] = transaction
+		heap.Fix(p, 0)
+	}
+}
+
+func (p *transactionPage) sorted() []domain.Transaction {
+	sort.Slice(p.items, func(i, j int) bool {
+		return p.query.Before(p.items[i], p.items[j])
+	})
+	return p.items
+}
+
+func (p *transactionPage) Len() int { return len(p.items) }
+
+func (p *transactionPage) Less(i, j int) bool { return p.query.Before(p.items[j], p.items[i]) }
+
+func (p *transactionPage) Swap(i, j int) { p.items[i], p.items[j] = p.items[j], p.items[i] }
+
+func (p *transactionPage) Push(x any) { p.items = append(p.items, x.(domain.Transaction)) }
+
+func (p *transactionPage) Pop() any {
+	last := p.items[len(p.items)-1]
+	p.items = p.items[:len(p.items)-1]
+	return last
+}
+
+// scanInOrder walks date-ordered entries of one index key in the query
+// direction, starting right after the cursor, and stops as soon as the page
+// is full.
+func (tr *TransactionRepository) scanInOrder(entries []indexEntry, key string, query domain.TransactionQuery) []domain.Transaction {
+	start, end := 0, len(entries)
+	if query.After != nil {
+		pivot := indexEntry{key: key, date: query.After.TransactionDate, id: query.After.ID}
+		if query.Descending {
+			end = sort.Search(len(entries), func(i int) bool {
+				return entries[i].compare(pivot) >= 0
+			})
+		} else {
+			start = sort.Search(len(entries), func(i int) bool {
+				return entries[i].compare(pivot) > 0
+			})
+		}
+	}
+
+	result := make([]domain.Transaction, 0)
+	for n := 0; n < end-start; n++ {
+		i := start + n
+		if query.Descending {
+			i = end - 1 - n
+		}
+
+		transaction := tr.store[entries[i].id]
+		if !query.Filter.Matches(transaction) {
+			continue
+		}
+
+		result = append(result, transaction)
+		if query.Limit > 0 && len(result) == query.Limit {
+			break
+		}
+	}
+
+	return result
+}
+
+func (tr *TransactionRepository) resolve(entries []indexEntry) []domain.Transaction {
+	result := make([]domain.Transaction, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, tr.store[entry.id])
+	}
+	return result
+}
+
+func (tr *TransactionRepository) index(transactions []domain.Transaction) {
+	byDate := make([]indexEntry, 0, len(transactions))
+	byStatus := make([]indexEntry, 0, len(transactions))
+	byName := make([]indexEntry, 0, len(transactions))
+	for _, transaction := range transactions {
+		tr.applyTotals(transaction, 1)
+		date, status, name := indexEntriesOf(transaction)
+		byDate = append(byDate, date)
+		byStatus = append(byStatus, status)
+		byName = append(byName, name)
+	}
+
+	tr.byDate.insert(byDate)
+	tr.byStatus.insert(byStatus)
+	tr.byName.insert(byName)
+}
+
+func (tr *TransactionRepository) unindex(transactions []domain.Transaction) {
+	ids := make(map[uuid.UUID]struct{}, len(transactions))
+	for _, transaction := range transactions {
+		tr.applyTotals(transaction, -1)
+		ids[transaction.ID] = struct{}{}
+	}
+
+	tr.byDate.remove(ids)
+	tr.byStatus.remove(ids)
+	tr.byName.remove(ids)
+}
+
+// reindex moves a single updated transaction within the indexes and totals.
+func (tr *TransactionRepository) reindex(old, updated domain.Transaction) {
+	tr.applyTotals(old, -1)
+	tr.applyTotals(updated, 1)
+
+	oldDate, oldStatus, oldName := indexEntriesOf(old)
+	date, status, name := indexEntriesOf(updated)
+	for _, change := range []struct {
+		index      *orderedIndex
+		old, entry indexEntry
+	}{
+		{&tr.byDate, oldDate, date},
+		{&tr.byStatus, oldStatus, status},
+		{&tr.byName, oldName, name},
+	} {
+		if change.old != change.entry {
+			change.index.removeEntry(change.old)
+			change.index.insertEntry(change.entry)
+		}
+	}
+}
+
+func indexEntriesOf(transaction domain.Transaction) (byDate, byStatus, byName indexEntry) {
+	byDate = indexEntry{date: transaction.TransactionDate, id: transaction.ID}
+	byStatus = indexEntry{key: string(transaction.Status), date: transaction.TransactionDate, id: transaction.ID}
+	byName = indexEntry{key: domain.CounterpartyKey(transaction.Name), date: transaction.TransactionDate, id: transaction.ID}
+	return byDate, byStatus, byName
+}
+
+func (tr *TransactionRepository) applyTotals(transaction domain.Transaction, sign int64) {
+	key := balanceKey{currency: transaction.EffectiveCurrency(), status: transaction.Status}
+	totals := tr.totals[key]
+	totals.Apply(transaction, sign)
+	if totals.Transactions == 0 {
+		delete(tr.totals, key)
+		return
+	}
+	tr.totals[key] = totals
+}
+
+// SaveTransactions inserts transactions whose ID is not stored yet. A known
+// ID is reported as a duplicate when the content matches and as a conflict
+// otherwise; the stored transaction is kept in both cases.
+func (tr *TransactionRepository) SaveTransactions(transactions []domain.Transaction) (domain.SaveResult, error) {
 	tr.mutex.Lock()
 	defer tr.mutex.Unlock()
 
+	var result domain.SaveResult
+	inserted := make([]domain.Transaction, 0, len(transactions))
 	for _, transaction := range transactions {
-		tr.store[transaction.ID] = transaction
+		existing, exists := tr.store[transaction.ID]
+		switch {
+		case !exists:
+			tr.store[transaction.ID] = transaction
+			inserted = append(inserted, transaction)
+			result.Add(domain.SaveOutcomeInserted)
+		case existing.Fingerprint() == transaction.Fingerprint():
+			result.Add(domain.SaveOutcomeDuplicate)
+		default:
+			result.Add(domain.SaveOutcomeConflict)
+		}
+	}
+	tr.index(inserted)
+
+	return result, nil
+}
+
+// UpdateTransaction applies update to a copy of the stored transaction and
+// stores the result, keeping indexes and totals in step. Nothing changes
+// when update returns an error.
+func (tr *TransactionRepository) UpdateTransaction(id uuid.UUID, update func(*domain.Transaction) error) (domain.Transaction, error) {
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+
+	existing, ok := tr.store[id]
+	if !ok {
+		return domain.Transaction{}, ErrTransactionNotFound
+	}
+
+	updated := existing
+	updated.StatusHistory = slices.Clone(existing.StatusHistory)
+	if err := update(&updated); err != nil {
+		return domain.Transaction{}, err
+	}
+	updated.ID = id
+
+	tr.reindex(existing, updated)
+	tr.store[id] = updated
+
+	return updated, nil
+}
+
+// DeleteTransactionsByUpload removes every transaction linked to the upload
+// in a single critical section and returns the removed transactions. It
+// removes nothing and returns ErrUploadReversed when one of them is reversed
+// by a transaction of another upload, which would be left undoing a missing
+// transaction.
+func (tr *TransactionRepository) DeleteTransactionsByUpload(uploadID uuid.UUID) ([]domain.Transaction, error) {
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+
+	if err := tr.reversedElsewhere(uploadID); err != nil {
+		return nil, err
+	}
+
+	removed := make([]domain.Transaction, 0)
+	for id, transaction := range tr.store {
+		if transaction.UploadID == uploadID {
+			removed = append(removed, transaction)
+			delete(tr.store, id)
+		}
+	}
+	tr.unindex(removed)
+
+	return removed, nil
+}
+
+// checkUploadDeletable reports the ErrUploadReversed DeleteTransactionsByUpload
+// would return, so a caller serialising its writes can check before logging.
+func (tr *TransactionRepository) checkUploadDeletable(uploadID uuid.UUID) error {
+	tr.mutex.RLock()
+	defer tr.mutex.RUnlock()
+
+	return tr.reversedElsewhere(uploadID)
+}
+
+func (tr *TransactionRepository) reversedElsewhere(uploadID uuid.UUID) error {
+	for _, original := range tr.store {
+		if original.UploadID != uploadID || original.ReversedBy == uuid.Nil {
+			continue
+		}
+		if reversal, ok := tr.store[original.ReversedBy]; ok && reversal.UploadID != uploadID {
+			return fmt.Errorf("%w: %s is reversed by %s in upload %s", ErrUploadReversed, original.ID, reversal.ID, reversal.UploadID)
+		}
 	}
+	return nil
 }
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"flip-test/internal/domain"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const benchmarkTransactions = 200_000
+
+var benchmarkBase = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newBenchmarkRepository stores transactions one minute apart where one in
+// a hundred is not SUCCESS, roughly the shape of production data.
+func newBenchmarkRepository(b *testing.B) *TransactionRepository {
+	b.Helper()
+
+	transactions := make([]domain.Transaction, benchmarkTransactions)
+	for i := range transactions {
+		status := domain.TransactionStatusSuccess
+		switch i % 100 {
+		case 0:
+			status = domain.TransactionStatusFailed
+		case 50:
+			status = domain.TransactionStatusPending
+		}
+
+		transactions[i] = domain.Transaction{
+			ID:              uuid.New(),
+			Name:            "Counterparty",
+			Type:            domain.TransactionTypeCredit,
+			Amount:          1000,
+			Status:          status,
+			TransactionDate: benchmarkBase.Add(time.Duration(i) * time.Minute),
+		}
+	}
+
+	repo := NewTransactionRepository()
+	if _, err := repo.SaveTransactions(transactions); err != nil {
+		b.Fatal(err)
+	}
+	return repo
+}
+
+var unsuccessfulQuery = domain.TransactionQuery{
+	Filter: domain.TransactionFilter{
+		Statuses: []domain.TransactionStatus{domain.TransactionStatusFailed, domain.TransactionStatusPending},
+	},
+	SortBy:     domain.SortByTransactionDate,
+	Descending: true,
+}
+
+func BenchmarkUnsuccessful_FullScan(b *testing.B) {
+	repo := newBenchmarkRepository(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		result := make([]domain.Transaction, 0)
+		for _, transaction := range repo.GetTransactions() {
+			if transaction.Status != domain.TransactionStatusSuccess {
+				result = append(result, transaction)
+			}
+		}
+		sort.Slice(result, func(i, j int) bool {
+			return result[i].TransactionDate.After(result[j].TransactionDate)
+		})
+	}
+}
+
+func BenchmarkUnsuccessful_StatusIndex(b *testing.B) {
+	repo := newBenchmarkRepository(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		repo.ListTransactions(unsuccessfulQuery)
+	}
+}
+
+func BenchmarkDateRange_FullScan(b *testing.B) {
+	repo := newBenchmarkRepository(b)
+	from, to := benchmarkBase.Add(24*time.Hour), benchmarkBase.Add(48*time.Hour)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		result := make([]domain.Transaction, 0)
+		for _, transaction := range repo.GetTransactions() {
+			if !transaction.TransactionDate.Before(from) && !transaction.TransactionDate.After(to) {
+				result = append(result, transaction)
+			}
+		}
+		sort.Slice(result, func(i, j int) bool {
+			return result[i].TransactionDate.Before(result[j].TransactionDate)
+		})
+	}
+}
+
+func BenchmarkDateRange_DateIndex(b *testing.B) {
+	repo := newBenchmarkRepository(b)
+	query := domain.TransactionQuery{
+		Filter: domain.TransactionFilter{From: benchmarkBase.Add(24 * time.Hour), To: benchmarkBase.Add(48 * time.Hour)},
+		SortBy: domain.SortByTransactionDate,
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		repo.ListTransactions(query)
+	}
+}
+
+func BenchmarkLatestPage_DateIndex(b *testing.B) {
+	repo := newBenchmarkRepository(b)
+	query := domain.TransactionQuery{SortBy: domain.SortByTransactionDate, Descending: true, Limit: 50}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		repo.ListTransactions(query)
+	}
+}
+
+func BenchmarkLargestPage_TopK(b *testing.B) {
+	repo := newBenchmarkRepository(b)
+	query := domain.TransactionQuery{SortBy: domain.SortByAmount, Descending: true, Limit: 50}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		repo.ListTransactions(query)
+	}
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"errors"
+	"flip-test/internal/domain"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestTransactionRepository_BalanceSummaryFollowsWrites(t *testing.T) {
+	repo := NewTransactionRepository()
+	uploadID := uuid.New()
+
+	credit := newStoredTransaction("Alice", 5000, uploadID)
+	debit := newStoredTransaction("Bob", 2000, uploadID)
+	debit.Type = domain.TransactionTypeDebit
+	pending := newStoredTransaction("Carol", 700, uuid.New())
+	pending.Status = domain.TransactionStatusPending
+
+	if _, err := repo.SaveTransactions([]domain.Transaction{credit, debit, pending}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	// Duplicates must not be counted twice.
+	if _, err := repo.SaveTransactions([]domain.Transaction{credit}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	success := repo.GetBalanceSummary()[domain.DefaultCurrency][domain.TransactionStatusSuccess]
+	expected := domain.BalanceTotals{Credits: 5000, Debits: 2000, Net: 3000, Transactions: 2}
+	if success != expected {
+		t.Errorf("Expected %+v, got %+v", expected, success)
+	}
+	if totals := repo.GetBalanceSummary()[domain.DefaultCurrency][domain.TransactionStatusPending]; totals.Net != 700 {
+		t.Errorf("Expected pending net 700, got %d", totals.Net)
+	}
+
+	if _, err := repo.DeleteTransactionsByUpload(uploadID); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if _, ok := repo.GetBalanceSummary()[domain.DefaultCurrency][domain.TransactionStatusSuccess]; ok {
+		t.Error("Expected success totals to be gone after the upload was removed")
+	}
+}
+
+func TestTransactionRepository_BalanceSummaryUnderConcurrentSaves(t *testing.T) {
+	repo := NewTransactionRepository()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				repo.SaveTransactions([]domain.Transaction{newStoredTransaction("Alice", 10, uuid.Nil)})
+				repo.GetBalanceSummary()
+			}
+		}()
+	}
+	wg.Wait()
+
+	totals := repo.GetBalanceSummary()[domain.DefaultCurrency][domain.TransactionStatusSuccess]
+	if totals.Net != 8000 || totals.Transactions != 800 {
+		t.Errorf("Expected net 8000 over 800 transactions, got %+v", totals)
+	}
+}
+
+func TestTransactionRepository_DeleteKeepsUploadReversedElsewhere(t *testing.T) {
+	repo := NewTransactionRepository()
+	originalUpload, reversalUpload := uuid.New(), uuid.New()
+
+	original := newStoredTransaction("Alice", 5000, originalUpload)
+	reversal := newStoredTransaction("Alice", 5000, reversalUpload)
+	reversal.Type = domain.TransactionTypeDebit
+	original.ReversedBy = reversal.ID
+	if _, err := repo.SaveTransactions([]domain.Transaction{original, reversal}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if _, err := repo.DeleteTransactionsByUpload(originalUpload); !errors.Is(err, ErrUploadReversed) {
+		t.Fatalf("Expected ErrUploadReversed, got: %v", err)
+	}
+	if _, ok := repo.GetTransaction(original.ID); !ok {
+		t.Fatal("Expected the original to be kept")
+	}
+
+	// Rolling back the reversal first frees the original.
+	if _, err := repo.DeleteTransactionsByUpload(reversalUpload); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if removed, err := repo.DeleteTransactionsByUpload(originalUpload); err != nil || len(removed) != 1 {
+		t.Errorf("Expected the original to be removed, got %d: %v", len(removed), err)
+	}
+}
+
+func TestTransactionRepository_ListPagesByAmount(t *testing.T) {
+	repo := NewTransactionRepository()
+	transactions := make([]domain.Transaction, 0)
+	for _, amount := range []int64{700, 100, 900, 300, 900, 500} {
+		transactions = append(transactions, newStoredTransaction("Alice", amount, uuid.Nil))
+	}
+	if _, err := repo.SaveTransactions(transactions); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	query := domain.TransactionQuery{SortBy: domain.SortByAmount, Descending: true}
+	all := repo.ListTransactions(query)
+
+	query.Limit = 4
+	var paged []domain.Transaction
+	for {
+		page := repo.ListTransactions(query)
+		paged = append(paged, page...)
+		if len(page) < query.Limit {
+			break
+		}
+		query.After = &page[len(page)-1]
+	}
+
+	if len(paged) != len(all) {
+		t.Fatalf("Expected %d transactions across pages, got %d", len(all), len(paged))
+	}
+	for i := range all {
+		if paged[i].ID != all[i].ID {
+			t.Fatalf("Expected the pages to follow the unpaged order, differing at %d", i)
+		}
+	}
+	if all[0].Amount != 900 || all[len(all)-1].Amount != 100 {
+		t.Errorf("Expected amounts from 900 down to 100, got %d to %d", all[0].Amount, all[len(all)-1].Amount)
+	}
+}
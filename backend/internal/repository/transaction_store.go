@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"flip-test/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// TransactionStore is the storage the services depend on. TransactionRepository
+// keeps transactions in memory only; FileTransactionStore also persists them.
+type TransactionStore interface {
+	GetTransactions() []domain.Transaction
+	GetTransaction(id uuid.UUID) (domain.Transaction, bool)
+	GetTransactionsByUpload(uploadID uuid.UUID) []domain.Transaction
+	GetTransactionsByName(name string) []domain.Transaction
+	// ListTransactions returns up to query.Limit transactions matching the
+	// filter that come after query.After in the query order.
+	ListTransactions(query domain.TransactionQuery) []domain.Transaction
+	// GetBalanceSummary returns running totals kept up to date by every write.
+	GetBalanceSummary() domain.BalanceSummary
+	SaveTransactions(transactions []domain.Transaction) (domain.SaveResult, error)
+	// UpdateTransaction changes one stored transaction through update and
+	// returns ErrTransactionNotFound for an unknown ID.
+	UpdateTransaction(id uuid.UUID, update func(*domain.Transaction) error) (domain.Transaction, error)
+	DeleteTransactionsByUpload(uploadID uuid.UUID) ([]domain.Transaction, error)
+}
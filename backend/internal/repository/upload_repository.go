@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"flip-test/internal/domain"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var _ UploadStore = (*UploadRepository)(nil)
+
+type UploadRepository struct {
+	store map[uuid.UUID]domain.Upload
+	mutex sync.RWMutex
+}
+
+func NewUploadRepository() *UploadRepository {
+	return &UploadRepository{store: make(map[uuid.UUID]domain.Upload)}
+}
+
+func (ur *UploadRepository) SaveUpload(upload domain.Upload) error {
+	ur.mutex.Lock()
+	defer ur.mutex.Unlock()
+
+	ur.store[upload.ID] = upload
+	return nil
+}
+
+func (ur *UploadRepository) GetUpload(id uuid.UUID) (domain.Upload, bool) {
+	ur.mutex.RLock()
+	defer ur.mutex.RUnlock()
+
+	upload, ok := ur.store[id]
+	return upload, ok
+}
+
+// GetUploads returns every upload, newest first.
+func (ur *UploadRepository) GetUploads() []domain.Upload {
+	ur.mutex.RLock()
+	defer ur.mutex.RUnlock()
+
+	result := make([]domain.Upload, 0, len(ur.store))
+	for _, upload := range ur.store {
+		result = append(result, upload)
+	}
+
+	sortNewestFirst(result)
+	return result
+}
+
+func sortNewestFirst(uploads []domain.Upload) {
+	sort.Slice(uploads, func(i, j int) bool {
+		return uploads[i].UploadedAt.After(uploads[j].UploadedAt)
+	})
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"flip-test/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// UploadStore keeps upload records. UploadRepository keeps them in memory
+// only; FileUploadStore also persists them.
+type UploadStore interface {
+	SaveUpload(upload domain.Upload) error
+	GetUpload(id uuid.UUID) (domain.Upload, bool)
+	// GetUploads returns every upload, newest first.
+	GetUploads() []domain.Upload
+}
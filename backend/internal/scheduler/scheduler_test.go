@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler_RunsJobsUntilStopped(t *testing.T) {
+	var runs atomic.Int32
+	ran := make(chan struct{}, 1)
+
+	scheduler := NewScheduler(Job{
+		Name:     "count",
+		Interval: time.Millisecond,
+		Run: func(ctx context.Context) error {
+			runs.Add(1)
+			select {
+			case ran <- struct{}{}:
+			default:
+			}
+			return nil
+		},
+	})
+	scheduler.Start()
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the job to run")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := scheduler.Stop(ctx); err != nil {
+		t.Fatalf("Expected a clean stop, got: %v", err)
+	}
+
+	stopped := runs.Load()
+	time.Sleep(10 * time.Millisecond)
+	if runs.Load() != stopped {
+		t.Error("Expected no runs after Stop returned")
+	}
+}
+
+func TestScheduler_StopWaitsForRunningJob(t *testing.T) {
+	started := make(chan struct{})
+	finished := make(chan struct{})
+
+	scheduler := NewScheduler(Job{
+		Name:     "slow",
+		Interval: time.Millisecond,
+		Run: func(ctx context.Context) error {
+			select {
+			case <-started:
+			default:
+				close(started)
+			}
+			<-ctx.Done()
+			close(finished)
+			return ctx.Err()
+		},
+	})
+	scheduler.Start()
+	<-started
+
+	if err := scheduler.Stop(context.Background()); err != nil {
+		t.Fatalf("Expected a clean stop, got: %v", err)
+	}
+
+	select {
+	case <-finished:
+	default:
+		t.Error("Expected Stop to wait for the running job to return")
+	}
+}
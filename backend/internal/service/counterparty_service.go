@@ -0,0 +1,237 @@
+package service
+
+import (
+	"errors"
+	"flip-test/internal/domain"
+	"flip-test/internal/repository"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrCounterpartyNotFound = errors.New("counterparty not found")
+
+type CounterpartyService struct {
+	TransactionStore repository.TransactionStore
+}
+
+func NewCounterpartyService(store repository.TransactionStore) *CounterpartyService {
+	return &CounterpartyService{
+		TransactionStore: store,
+	}
+}
+
+// GetCounterparties summarises every counterparty per currency, ordered by
+// name and currency. An empty currency includes all currencies.
+func (cs *CounterpartyService) GetCounterparties(currency domain.Currency) []domain.CounterpartySummary {
+	type summaryKey struct {
+		counterparty string
+		currency     domain.Currency
+	}
+
+	summaries := make(map[summaryKey]*domain.CounterpartySummary)
+	for _, transaction := range cs.TransactionStore.GetTransactions() {
+		if currency != "" && transaction.EffectiveCurrency() != currency {
+			continue
+		}
+
+		key := summaryKey{counterparty: domain.CounterpartyKey(transaction.Name), currency: transaction.EffectiveCurrency()}
+		summary, ok := summaries[key]
+		if !ok {
+			summary = &domain.CounterpartySummary{
+				Currency: key.currency,
+				Counts:   make(map[domain.TransactionStatus]int, len(domain.TransactionStatuses)),
+			}
+			for _, status := range domain.TransactionStatuses {
+				summary.Counts[status] = 0
+			}
+			summaries[key] = summary
+		}
+
+		summary.Transactions++
+		summary.Counts[transaction.Status]++
+		if summary.Name == "" || transaction.TransactionDate.After(summary.LastActivity) {
+			summary.Name = transaction.Name
+			summary.LastActivity = transaction.TransactionDate
+		}
+		if transaction.Status != domain.TransactionStatusSuccess {
+			continue
+		}
+		if transaction.Type == domain.TransactionTypeCredit {
+			summary.Credits += transaction.Amount
+		} else {
+			summary.Debits += transaction.Amount
+		}
+		summary.Net = summary.Credits - summary.Debits
+	}
+
+	result := make([]domain.CounterpartySummary, 0, len(summaries))
+	for _, summary := range summaries {
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Currency < result[j].Currency
+	})
+
+	return result
+}
+
+// GetLedger returns the transactions of one counterparty in one currency in
+// TransactionDate order with the running balance after each of them. Any
+// spelling of the name with the same domain.CounterpartyKey matches.
+func (cs *CounterpartyService) GetLedger(name string, currency domain.Currency) (domain.CounterpartyLedger, error) {
+	minorUnits, ok := currency.MinorUnits()
+	if !ok {
+		return domain.CounterpartyLedger{}, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
+
+	transactions := cs.TransactionStore.GetTransactionsByName(name)
+	if len(transactions) == 0 {
+		return domain.CounterpartyLedger{}, ErrCounterpartyNotFound
+	}
+
+	ledger := domain.CounterpartyLedger{
+		Name:       transactions[len(transactions)-1].Name,
+		Currency:   currency,
+		MinorUnits: minorUnits,
+		Entries:    make([]domain.LedgerEntry, 0, len(transactions)),
+	}
+	for _, transaction := range transactions {
+		if transaction.EffectiveCurrency() != currency {
+			continue
+		}
+
+		if transaction.Status == domain.TransactionStatusSuccess {
+			if transaction.Type == domain.TransactionTypeCredit {
+				ledger.Balance += transaction.Amount
+			} else {
+				ledger.Balance -= transaction.Amount
+			}
+		}
+		ledger.Entries = append(ledger.Entries, domain.LedgerEntry{
+			Transaction:    transaction,
+			RunningBalance: ledger.Balance,
+		})
+	}
+
+	return ledger, nil
+}
+
+// SuggestMerges compares pairs of distinct counterparties and proposes
+// merging those whose names are at least threshold similar. Similarity is
+// the normalized edit distance of the case-folded names, also tried with
+// their words sorted so "Doe John" matches "John Doe". The edit distance is
+// at least the difference in length, so only names close enough in length
+// to reach threshold are compared.
+func (cs *CounterpartyService) SuggestMerges(threshold float64) []domain.NameSuggestion {
+	type counterparty struct {
+		key    string
+		name   string
+		length int
+		count  int
+	}
+
+	byKey := make(map[string]*counterparty)
+	for _, transaction := range cs.TransactionStore.GetTransactions() {
+		key := domain.CounterpartyKey(transaction.Name)
+		if _, ok := byKey[key]; !ok {
+			byKey[key] = &counterparty{key: key, name: transaction.Name, length: utf8.RuneCountInString(key)}
+		}
+		byKey[key].count++
+	}
+
+	counterparties := make([]*counterparty, 0, len(byKey))
+	for _, c := range byKey {
+		counterparties = append(counterparties, c)
+	}
+	sort.Slice(counterparties, func(i, j int) bool {
+		if counterparties[i].length != counterparties[j].length {
+			return counterparties[i].length < counterparties[j].length
+		}
+		return counterparties[i].key < counterparties[j].key
+	})
+
+	suggestions := make([]domain.NameSuggestion, 0)
+	for i, a := range counterparties {
+		for _, b := range counterparties[i+1:] {
+			if 1-float64(b.length-a.length)/float64(max(b.length, 1)) < threshold {
+				break
+			}
+			similarity := nameSimilarity(a.key, b.key)
+			if similarity < threshold {
+				continue
+			}
+
+			first, second := a, b
+			if second.key < first.key {
+				first, second = second, first
+			}
+
+			suggested := first.name
+			if second.count > first.count {
+				suggested = second.name
+			}
+			suggestions = append(suggestions, domain.NameSuggestion{
+				Names:      []string{first.name, second.name},
+				Suggested:  suggested,
+				Similarity: math.Round(similarity*1000) / 1000,
+			})
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Similarity != suggestions[j].Similarity {
+			return suggestions[i].Similarity > suggestions[j].Similarity
+		}
+		return strings.Join(suggestions[i].Names, "\x1f") < strings.Join(suggestions[j].Names, "\x1f")
+	})
+
+	return suggestions
+}
+
+func nameSimilarity(a, b string) float64 {
+	similarity := editSimilarity(a, b)
+	if sorted := editSimilarity(sortWords(a), sortWords(b)); sorted > similarity {
+		similarity = sorted
+	}
+	return similarity
+}
+
+func sortWords(value string) string {
+	words := strings.Fields(value)
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// editSimilarity is 1 minus the Levenshtein distance over the longer length,
+// counted in runes.
+func editSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return 1 - float64(previous[len(rb)])/float64(longest)
+}
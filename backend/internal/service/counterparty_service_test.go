@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"flip-test/internal/domain"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestCounterpartyService(t *testing.T) *CounterpartyService {
+	t.Helper()
+
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+	transactions := []domain.Transaction{
+		{ID: uuid.New(), Name: "Alice", Type: domain.TransactionTypeCredit, Amount: 5000, Status: domain.TransactionStatusSuccess, TransactionDate: day(1)},
+		{ID: uuid.New(), Name: "Alice", Type: domain.TransactionTypeDebit, Amount: 2000, Status: domain.TransactionStatusSuccess, TransactionDate: day(3)},
+		{ID: uuid.New(), Name: "Alice", Type: domain.TransactionTypeDebit, Amount: 700, Status: domain.TransactionStatusPending, TransactionDate: day(4)},
+		{ID: uuid.New(), Name: "Alice", Type: domain.TransactionTypeCredit, Amount: 900, Currency: "USD", Status: domain.TransactionStatusSuccess, TransactionDate: day(2)},
+		{ID: uuid.New(), Name: "Bob", Type: domain.TransactionTypeDebit, Amount: 300, Status: domain.TransactionStatusFailed, TransactionDate: day(5)},
+	}
+
+	_, repo := seedTransactions(t, transactions...)
+	return NewCounterpartyService(repo)
+}
+
+func TestGetCounterparties(t *testing.T) {
+	service := newTestCounterpartyService(t)
+
+	summaries := service.GetCounterparties("")
+	if len(summaries) != 3 {
+		t.Fatalf("Expected Alice IDR, Alice USD and Bob IDR, got %+v", summaries)
+	}
+
+	alice := summaries[0]
+	if alice.Name != "Alice" || alice.Currency != domain.DefaultCurrency {
+		t.Fatalf("Expected Alice IDR first, got %s %s", alice.Name, alice.Currency)
+	}
+	if alice.Credits != 5000 || alice.Debits != 2000 || alice.Net != 3000 {
+		t.Errorf("Expected successful totals 5000/2000/3000, got %+v", alice)
+	}
+	if alice.Transactions != 3 || alice.Counts[domain.TransactionStatusPending] != 1 {
+		t.Errorf("Expected 3 transactions with 1 pending, got %+v", alice)
+	}
+	if !alice.LastActivity.Equal(time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected last activity on May 4th, got %v", alice.LastActivity)
+	}
+
+	bob := summaries[2]
+	if bob.Net != 0 || bob.Counts[domain.TransactionStatusFailed] != 1 {
+		t.Errorf("Expected failed transactions to leave Bob's position at 0, got %+v", bob)
+	}
+
+	if usd := service.GetCounterparties("USD"); len(usd) != 1 || usd[0].Net != 900 {
+		t.Errorf("Expected only Alice's USD position, got %+v", usd)
+	}
+}
+
+func TestGetLedger_RunningBalance(t *testing.T) {
+	service := newTestCounterpartyService(t)
+
+	ledger, err := service.GetLedger("Alice", domain.DefaultCurrency)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := []int64{5000, 3000, 3000}
+	if len(ledger.Entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(ledger.Entries))
+	}
+	for i, entry := range ledger.Entries {
+		if entry.RunningBalance != expected[i] {
+			t.Errorf("Entry %d: expected running balance %d, got %d", i, expected[i], entry.RunningBalance)
+		}
+	}
+	if ledger.Balance != 3000 {
+		t.Errorf("Expected balance 3000, got %d", ledger.Balance)
+	}
+}
+
+func TestGetLedger_UnknownCounterparty(t *testing.T) {
+	service := newTestCounterpartyService(t)
+
+	if _, err := service.GetLedger("Nobody", domain.DefaultCurrency); !errors.Is(err, ErrCounterpartyNotFound) {
+		t.Errorf("Expected ErrCounterpartyNotFound, got: %v", err)
+	}
+}
+
+func TestGetCounterparties_GroupsNameVariants(t *testing.T) {
+	transactions := []domain.Transaction{
+		{ID: uuid.New(), Name: "John Doe", Type: domain.TransactionTypeCredit, Amount: 1000, Status: domain.TransactionStatusSuccess,
+			TransactionDate: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: uuid.New(), Name: "JOHN DOE", Type: domain.TransactionTypeCredit, Amount: 500, Status: domain.TransactionStatusSuccess,
+			TransactionDate: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	_, repo := seedTransactions(t, transactions...)
+	service := NewCounterpartyService(repo)
+
+	summaries := service.GetCounterparties("")
+	if len(summaries) != 1 || summaries[0].Net != 1500 || summaries[0].Name != "JOHN DOE" {
+		t.Errorf("Expected one counterparty named after its latest transaction, got %+v", summaries)
+	}
+
+	ledger, err := service.GetLedger("john doe", domain.DefaultCurrency)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(ledger.Entries) != 2 {
+		t.Errorf("Expected both spellings in the ledger, got %d entries", len(ledger.Entries))
+	}
+}
+
+func TestSuggestMerges(t *testing.T) {
+	names := []string{"Jonathan Smith", "Jonathan Smith", "Jonathon Smith", "Smith Jonathan", "Acme Corp"}
+	transactions := make([]domain.Transaction, 0, len(names))
+	for _, name := range names {
+		transactions = append(transactions, domain.Transaction{
+			ID: uuid.New(), Name: name, Type: domain.TransactionTypeCredit, Amount: 100, Status: domain.TransactionStatusSuccess,
+		})
+	}
+	_, repo := seedTransactions(t, transactions...)
+
+	suggestions := NewCounterpartyService(repo).SuggestMerges(0.9)
+	if len(suggestions) != 3 {
+		t.Fatalf("Expected 3 suggestions among the Smith variants, got %+v", suggestions)
+	}
+	for _, suggestion := range suggestions {
+		for _, name := range suggestion.Names {
+			if name == "Acme Corp" {
+				t.Errorf("Expected Acme Corp not to be suggested, got %+v", suggestion)
+			}
+		}
+	}
+	if suggestions[0].Similarity != 1 || suggestions[0].Suggested != "Jonathan Smith" {
+		t.Errorf("Expected the reordered name first, merged into the most used spelling, got %+v", suggestions[0])
+	}
+}
+
+func TestSuggestMerges_ComparesNamesOfDifferentLength(t *testing.T) {
+	transactions := make([]domain.Transaction, 0)
+	for _, name := range []string{"jon smith", "jonathan smith", "jonathan smithson the third"} {
+		transactions = append(transactions, domain.Transaction{
+			ID: uuid.New(), Name: name, Type: domain.TransactionTypeCredit, Amount: 100, Status: domain.TransactionStatusSuccess,
+		})
+	}
+	_, repo := seedTransactions(t, transactions...)
+
+	// The edit distance equals the length difference, just within the
+	// threshold; the longest name is too long to reach it.
+	suggestions := NewCounterpartyService(repo).SuggestMerges(0.6)
+	if len(suggestions) != 1 || !slices.Equal(suggestions[0].Names, []string{"jon smith", "jonathan smith"}) {
+		t.Errorf("Expected only the two Smith spellings to be suggested, got %+v", suggestions)
+	}
+}
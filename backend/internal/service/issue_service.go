@@ -0,0 +1,123 @@
+package service
+
+import (
+	"errors"
+	"flip-test/internal/domain"
+	"flip-test/internal/repository"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoIssue           = errors.New("transaction is successful and has no issue")
+	ErrEmptyIssueUpdate  = errors.New("issue update must change the state, the assignee or add a note")
+	ErrInvalidIssueState = errors.New("invalid issue state")
+)
+
+type IssueService struct {
+	IssueStore         repository.IssueStore
+	TransactionService *TransactionService
+	updateMutex        sync.Mutex
+}
+
+func NewIssueService(store repository.IssueStore, ts *TransactionService) *IssueService {
+	return &IssueService{
+		IssueStore:         store,
+		TransactionService: ts,
+	}
+}
+
+// GetIssues returns the issue of every unsuccessful transaction that matches
+// filter, most recent transaction first.
+func (is *IssueService) GetIssues(filter domain.IssueFilter) []domain.TransactionIssue {
+	issues := make([]domain.TransactionIssue, 0)
+	for _, transaction := range is.TransactionService.GetUnsuccessfulTransactions() {
+		issue := is.issueOf(transaction)
+		if filter.Matches(issue) {
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues
+}
+
+func (is *IssueService) GetIssue(transactionID uuid.UUID) (domain.TransactionIssue, error) {
+	transaction, err := is.TransactionService.GetTransaction(transactionID)
+	if err != nil {
+		return domain.TransactionIssue{}, err
+	}
+	if transaction.Status == domain.TransactionStatusSuccess {
+		return domain.TransactionIssue{}, ErrNoIssue
+	}
+
+	return is.issueOf(transaction), nil
+}
+
+// UpdateIssue applies update to the issue of an unsuccessful transaction.
+// Moving to resolved or ignored records when it was closed; moving back to
+// open or investigating clears it.
+func (is *IssueService) UpdateIssue(transactionID uuid.UUID, update domain.IssueUpdate, actor string) (domain.TransactionIssue, error) {
+	if update.State != nil {
+		state, ok := domain.ParseIssueState(string(*update.State))
+		if !ok {
+			return domain.TransactionIssue{}, fmt.Errorf("%w '%s'", ErrInvalidIssueState, *update.State)
+		}
+		update.State = &state
+	}
+	update.Note = strings.TrimSpace(update.Note)
+	if update.State == nil && update.Assignee == nil && update.Note == "" {
+		return domain.TransactionIssue{}, ErrEmptyIssueUpdate
+	}
+
+	is.updateMutex.Lock()
+	defer is.updateMutex.Unlock()
+
+	current, err := is.GetIssue(transactionID)
+	if err != nil {
+		return domain.TransactionIssue{}, err
+	}
+
+	now := time.Now().UTC()
+	issue := current.Issue
+	issue.Notes = slices.Clone(issue.Notes)
+	if issue.CreatedAt.IsZero() {
+		issue.CreatedAt = now
+	}
+	if update.State != nil && *update.State != issue.State {
+		issue.State = *update.State
+		issue.ClosedAt = nil
+		if issue.State.IsClosed() {
+			issue.ClosedAt = &now
+		}
+	}
+	if update.Assignee != nil {
+		issue.Assignee = strings.TrimSpace(*update.Assignee)
+	}
+	if update.Note != "" {
+		issue.Notes = append(issue.Notes, domain.IssueNote{Author: actor, Text: update.Note, CreatedAt: now})
+	}
+	issue.UpdatedAt = now
+
+	if err := is.IssueStore.SaveIssue(issue); err != nil {
+		return domain.TransactionIssue{}, err
+	}
+	return domain.TransactionIssue{Issue: issue, Transaction: current.Transaction}, nil
+}
+
+func (is *IssueService) issueOf(transaction domain.Transaction) domain.TransactionIssue {
+	issue, ok := is.IssueStore.GetIssue(transaction.ID)
+	if !ok {
+		issue = domain.Issue{
+			TransactionID: transaction.ID,
+			State:         domain.IssueStateOpen,
+			Notes:         make([]domain.IssueNote, 0),
+		}
+	}
+
+	return domain.TransactionIssue{Issue: issue, Transaction: transaction}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"flip-test/internal/domain"
+	"flip-test/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestIssueService(t *testing.T) (*IssueService, []domain.Transaction) {
+	t.Helper()
+
+	transactions := []domain.Transaction{
+		{ID: uuid.New(), Name: "Failed", Type: domain.TransactionTypeDebit, Amount: 100, Status: domain.TransactionStatusFailed,
+			TransactionDate: time.Date(2024, 8, 2, 0, 0, 0, 0, time.UTC)},
+		{ID: uuid.New(), Name: "Pending", Type: domain.TransactionTypeCredit, Amount: 200, Status: domain.TransactionStatusPending,
+			TransactionDate: time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: uuid.New(), Name: "Success", Type: domain.TransactionTypeCredit, Amount: 300, Status: domain.TransactionStatusSuccess,
+			TransactionDate: time.Date(2024, 8, 3, 0, 0, 0, 0, time.UTC)},
+	}
+	transactionService, _ := seedTransactions(t, transactions...)
+	return NewIssueService(repository.NewIssueRepository(), transactionService), transactions
+}
+
+func TestGetIssues_DefaultsToOpen(t *testing.T) {
+	service, transactions := newTestIssueService(t)
+
+	issues := service.GetIssues(domain.IssueFilter{})
+	if len(issues) != 2 {
+		t.Fatalf("Expected an issue per unsuccessful transaction, got %d", len(issues))
+	}
+	if issues[0].TransactionID != transactions[0].ID || issues[0].State != domain.IssueStateOpen || issues[0].Assignee != "" {
+		t.Errorf("Expected the most recent transaction first with an open unassigned issue, got %+v", issues[0])
+	}
+}
+
+func TestUpdateIssue_TracksStateAssigneeAndNotes(t *testing.T) {
+	service, transactions := newTestIssueService(t)
+	failed := transactions[0]
+
+	investigating, assignee := domain.IssueStateInvestigating, "dina"
+	issue, err := service.UpdateIssue(failed.ID, domain.IssueUpdate{State: &investigating, Assignee: &assignee, Note: "Asked the bank"}, "lead")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if issue.State != investigating || issue.Assignee != "dina" || len(issue.Notes) != 1 || issue.Notes[0].Author != "lead" {
+		t.Errorf("Unexpected issue after update: %+v", issue)
+	}
+	if issue.CreatedAt.IsZero() || issue.ClosedAt != nil {
+		t.Errorf("Expected a created and still open issue, got %+v", issue)
+	}
+
+	resolved := domain.IssueStateResolved
+	issue, err = service.UpdateIssue(failed.ID, domain.IssueUpdate{State: &resolved, Note: "Bank refunded the fee"}, "dina")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if issue.ClosedAt == nil || len(issue.Notes) != 2 || issue.Assignee != "dina" {
+		t.Errorf("Expected a closed issue keeping its assignee and notes, got %+v", issue)
+	}
+
+	byAssignee := service.GetIssues(domain.IssueFilter{Assignee: "DINA", States: []domain.IssueState{resolved}})
+	if len(byAssignee) != 1 || byAssignee[0].TransactionID != failed.ID {
+		t.Errorf("Expected to find the resolved issue by assignee, got %+v", byAssignee)
+	}
+	if unassigned := service.GetIssues(domain.IssueFilter{Unassigned: true}); len(unassigned) != 1 {
+		t.Errorf("Expected one unassigned issue, got %d", len(unassigned))
+	}
+}
+
+func TestUpdateIssue_Errors(t *testing.T) {
+	service, transactions := newTestIssueService(t)
+	invalid := domain.IssueState("done")
+	open := domain.IssueStateOpen
+
+	tests := []struct {
+		id       uuid.UUID
+		update   domain.IssueUpdate
+		expected error
+	}{
+		{transactions[0].ID, domain.IssueUpdate{Note: "  "}, ErrEmptyIssueUpdate},
+		{transactions[0].ID, domain.IssueUpdate{State: &invalid}, ErrInvalidIssueState},
+		{transactions[2].ID, domain.IssueUpdate{State: &open}, ErrNoIssue},
+		{uuid.New(), domain.IssueUpdate{State: &open}, ErrTransactionNotFound},
+	}
+	for _, test := range tests {
+		if _, err := service.UpdateIssue(test.id, test.update, "ops"); !errors.Is(err, test.expected) {
+			t.Errorf("Expected %v, got %v", test.expected, err)
+		}
+	}
+}
+
+func TestUpdateIssue_StoresParsedState(t *testing.T) {
+	service, transactions := newTestIssueService(t)
+
+	resolved := domain.IssueState(" Resolved ")
+	issue, err := service.UpdateIssue(transactions[0].ID, domain.IssueUpdate{State: &resolved}, "lead")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if issue.State != domain.IssueStateResolved || issue.ClosedAt == nil {
+		t.Errorf("Expected a closed issue in the resolved state, got %+v", issue)
+	}
+	if closed := service.GetIssues(domain.IssueFilter{States: []domain.IssueState{domain.IssueStateResolved}}); len(closed) != 1 {
+		t.Errorf("Expected the issue to be found by its resolved state, got %d", len(closed))
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"errors"
+	"flip-test/internal/domain"
+	"flip-test/internal/repository"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidTolerance = errors.New("tolerance must not be negative")
+
+// DefaultReconcileTolerance is how far apart the dates of a statement row
+// and a stored transaction may be for them to match on their details.
+const DefaultReconcileTolerance = 24 * time.Hour
+
+type ReconciliationService struct {
+	TransactionStore repository.TransactionStore
+}
+
+func NewReconciliationService(store repository.TransactionStore) *ReconciliationService {
+	return &ReconciliationService{
+		TransactionStore: store,
+	}
+}
+
+// Reconcile compares statement rows with the stored transactions without
+// changing either. A row first matches the stored transaction with its ID,
+// which comes from the reference when it has one. Otherwise it matches a
+// stored transaction with the same type, currency and counterparty dated
+// within tolerance, pairs with the same amount and then the closest dates
+// being taken first, so an amount gap is reported as a difference. Stored
+// transactions dated within the statement period that no row matched are
+// reported as stored only.
+func (rs *ReconciliationService) Reconcile(rows []domain.StatementRow, tolerance time.Duration) (domain.ReconciliationReport, error) {
+	if tolerance < 0 {
+		return domain.ReconciliationReport{}, ErrInvalidTolerance
+	}
+
+	report := domain.ReconciliationReport{
+		Tolerance:     tolerance.String(),
+		Matched:       make([]domain.ReconciliationMatch, 0),
+		Mismatched:    make([]domain.ReconciliationMatch, 0),
+		StatementOnly: make([]domain.StatementRow, 0),
+		StoredOnly:    make([]domain.Transaction, 0),
+	}
+	if len(rows) == 0 {
+		return report, nil
+	}
+
+	report.From, report.To = rows[0].Transaction.TransactionDate, rows[0].Transaction.TransactionDate
+	for _, row := range rows[1:] {
+		if date := row.Transaction.TransactionDate; date.Before(report.From) {
+			report.From = date
+		} else if date.After(report.To) {
+			report.To = date
+		}
+	}
+
+	matches := make([]*domain.ReconciliationMatch, len(rows))
+	matchedIDs := make(map[uuid.UUID]bool)
+	for i, row := range rows {
+		stored, ok := rs.TransactionStore.GetTransaction(row.Transaction.ID)
+		if !ok || matchedIDs[stored.ID] {
+			continue
+		}
+		matches[i] = newReconciliationMatch(row, stored, domain.MatchByID)
+		matchedIDs[stored.ID] = true
+	}
+
+	candidates := rs.TransactionStore.ListTransactions(domain.TransactionQuery{
+		Filter: domain.TransactionFilter{
+			From: report.From.Add(-tolerance),
+			To:   report.To.Add(tolerance),
+		},
+		SortBy: domain.SortByTransactionDate,
+	})
+	rs.matchByDetails(rows, candidates, tolerance, matches, matchedIDs)
+
+	for i, row := range rows {
+		switch match := matches[i]; {
+		case match == nil:
+			report.StatementOnly = append(report.StatementOnly, row)
+		case len(match.Differences) > 0:
+			report.Mismatched = append(report.Mismatched, *match)
+		default:
+			report.Matched = append(report.Matched, *match)
+		}
+	}
+
+	for _, stored := range candidates {
+		date := stored.TransactionDate
+		if !matchedIDs[stored.ID] && !date.Before(report.From) && !date.After(report.To) {
+			report.StoredOnly = append(report.StoredOnly, stored)
+		}
+	}
+
+	report.Summary = domain.ReconciliationSummary{
+		Matched:       len(report.Matched),
+		Mismatched:    len(report.Mismatched),
+		StatementOnly: len(report.StatementOnly),
+		StoredOnly:    len(report.StoredOnly),
+	}
+	return report, nil
+}
+
+// matchByDetails pairs the rows left unmatched with candidates sharing their
+// details. Every possible pair is ranked by whether the amounts agree, then
+// date distance, line and ID, and taken greedily, so a row never takes a
+// transaction that fits another row better.
+func (rs *ReconciliationService) matchByDetails(rows []domain.StatementRow, candidates []domain.Transaction, tolerance time.Duration, matches []*domain.ReconciliationMatch, matchedIDs map[uuid.UUID]bool) {
+	byDetails := make(map[string][]domain.Transaction)
+	for _, stored := range candidates {
+		if !matchedIDs[stored.ID] {
+			key := detailsKey(stored)
+			byDetails[key] = append(byDetails[key], stored)
+		}
+	}
+
+	type pair struct {
+		row        int
+		stored     domain.Transaction
+		sameAmount bool
+		distance   time.Duration
+	}
+
+	var pairs []pair
+	for i, row := range rows {
+		if matches[i] != nil {
+			continue
+		}
+		for _, stored := range byDetails[detailsKey(row.Transaction)] {
+			if row.Transaction.Reference != "" && stored.Reference != "" && row.Transaction.Reference != stored.Reference {
+				continue
+			}
+			distance := row.Transaction.TransactionDate.Sub(stored.TransactionDate).Abs()
+			if distance <= tolerance {
+				pairs = append(pairs, pair{row: i, stored: stored, sameAmount: row.Transaction.Amount == stored.Amount, distance: distance})
+			}
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].sameAmount != pairs[j].sameAmount {
+			return pairs[i].sameAmount
+		}
+		if pairs[i].distance != pairs[j].distance {
+			return pairs[i].distance < pairs[j].distance
+		}
+		if pairs[i].row != pairs[j].row {
+			return pairs[i].row < pairs[j].row
+		}
+		return pairs[i].stored.ID.String() < pairs[j].stored.ID.String()
+	})
+
+	for _, p := range pairs {
+		if matches[p.row] != nil || matchedIDs[p.stored.ID] {
+			continue
+		}
+		matches[p.row] = newReconciliationMatch(rows[p.row], p.stored, domain.MatchByDetails)
+		matchedIDs[p.stored.ID] = true
+	}
+}
+
+func detailsKey(t domain.Transaction) string {
+	return string(t.Type) + "\x1f" + string(t.EffectiveCurrency()) + "\x1f" + domain.CounterpartyKey(t.Name)
+}
+
+func newReconciliationMatch(row domain.StatementRow, stored domain.Transaction, method domain.MatchMethod) *domain.ReconciliationMatch {
+	match := &domain.ReconciliationMatch{
+		Line:      row.Line,
+		Statement: row.Transaction,
+		Stored:    stored,
+		MatchedBy: method,
+	}
+	if row.Transaction.Status != stored.Status {
+		match.Differences = append(match.Differences, "status")
+	}
+	if row.Transaction.Amount != stored.Amount || row.Transaction.EffectiveCurrency() != stored.EffectiveCurrency() {
+		match.Differences = append(match.Differences, "amount")
+	}
+	return match
+}
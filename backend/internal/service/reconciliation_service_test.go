@@ -0,0 +1,200 @@
+package service
+
+import (
+	"errors"
+	"flip-test/internal/domain"
+	"flip-test/internal/repository"
+	"slices"
+	"testing"
+	"time"
+)
+
+func reconcileDay(d int, hour int) time.Time {
+	return time.Date(2024, 6, d, hour, 0, 0, 0, time.UTC)
+}
+
+func newReconcileTransaction(reference, name string, amount int64, status domain.TransactionStatus, date time.Time) domain.Transaction {
+	transaction := domain.Transaction{
+		Reference:       reference,
+		Name:            name,
+		Type:            domain.TransactionTypeDebit,
+		Amount:          amount,
+		Currency:        domain.DefaultCurrency,
+		Status:          status,
+		TransactionDate: date,
+	}
+	transaction.ID = domain.NewTransactionID(transaction)
+	return transaction
+}
+
+func newTestReconciliationService(t *testing.T, stored ...domain.Transaction) (*ReconciliationService, *repository.TransactionRepository) {
+	t.Helper()
+
+	_, repo := seedTransactions(t, stored...)
+	return NewReconciliationService(repo), repo
+}
+
+func statementRows(transactions ...domain.Transaction) []domain.StatementRow {
+	rows := make([]domain.StatementRow, len(transactions))
+	for i, transaction := range transactions {
+		rows[i] = domain.StatementRow{Line: i + 2, Transaction: transaction}
+	}
+	return rows
+}
+
+func TestReconcileMatchesByReference(t *testing.T) {
+	stored := newReconcileTransaction("REF-1", "Alice", 1000, domain.TransactionStatusSuccess, reconcileDay(1, 9))
+	service, _ := newTestReconciliationService(t, stored)
+
+	// The bank reports the same reference on a different day and name.
+	statement := newReconcileTransaction("REF-1", "ALICE LTD", 1000, domain.TransactionStatusSuccess, reconcileDay(5, 9))
+	report, err := service.Reconcile(statementRows(statement), DefaultReconcileTolerance)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(report.Matched) != 1 || report.Matched[0].Stored.ID != stored.ID || report.Matched[0].MatchedBy != domain.MatchByID {
+		t.Fatalf("Expected a match by ID, got %+v", report)
+	}
+	if report.Summary != (domain.ReconciliationSummary{Matched: 1}) {
+		t.Errorf("Expected only the match, got %+v", report.Summary)
+	}
+}
+
+func TestReconcileMatchesByDetailsWithinTolerance(t *testing.T) {
+	stored := newReconcileTransaction("", "Alice", 1000, domain.TransactionStatusSuccess, reconcileDay(1, 9))
+	service, _ := newTestReconciliationService(t, stored)
+
+	statement := newReconcileTransaction("", " alice ", 1000, domain.TransactionStatusSuccess, reconcileDay(1, 20))
+	report, err := service.Reconcile(statementRows(statement), DefaultReconcileTolerance)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(report.Matched) != 1 || report.Matched[0].MatchedBy != domain.MatchByDetails {
+		t.Fatalf("Expected a match by details, got %+v", report)
+	}
+
+	report, err = service.Reconcile(statementRows(statement), 2*time.Hour)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if report.Summary.StatementOnly != 1 || report.Summary.Matched != 0 {
+		t.Errorf("Expected no match outside the tolerance, got %+v", report.Summary)
+	}
+}
+
+func TestReconcilePairsClosestDates(t *testing.T) {
+	early := newReconcileTransaction("", "Alice", 1000, domain.TransactionStatusSuccess, reconcileDay(1, 8))
+	late := newReconcileTransaction("", "Alice", 1000, domain.TransactionStatusSuccess, reconcileDay(1, 20))
+	service, _ := newTestReconciliationService(t, early, late)
+
+	// The first row is closer to early, but the second is closer still, so
+	// a greedy pass in row order would leave the second row unmatched.
+	first := newReconcileTransaction("", "Alice", 1000, domain.TransactionStatusSuccess, reconcileDay(1, 12))
+	second := newReconcileTransaction("", "Alice", 1000, domain.TransactionStatusSuccess, reconcileDay(1, 9))
+	report, err := service.Reconcile(statementRows(first, second), 12*time.Hour)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(report.Matched) != 2 {
+		t.Fatalf("Expected both rows to match, got %+v", report.Summary)
+	}
+	if report.Matched[0].Stored.ID != late.ID || report.Matched[1].Stored.ID != early.ID {
+		t.Errorf("Expected line 2 to match the late and line 3 the early transaction, got %+v", report.Matched)
+	}
+}
+
+func TestReconcileReportsDifferences(t *testing.T) {
+	stored := newReconcileTransaction("REF-1", "Alice", 1000, domain.TransactionStatusPending, reconcileDay(1, 9))
+	service, _ := newTestReconciliationService(t, stored)
+
+	statement := newReconcileTransaction("REF-1", "Alice", 1200, domain.TransactionStatusSuccess, reconcileDay(1, 9))
+	report, err := service.Reconcile(statementRows(statement), DefaultReconcileTolerance)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(report.Mismatched) != 1 {
+		t.Fatalf("Expected one mismatch, got %+v", report)
+	}
+	if differences := report.Mismatched[0].Differences; !slices.Equal(differences, []string{"status", "amount"}) {
+		t.Errorf("Expected status and amount to differ, got %v", differences)
+	}
+	if report.Summary.Matched != 0 || report.Summary.StoredOnly != 0 {
+		t.Errorf("Expected the mismatch to count as neither matched nor stored only, got %+v", report.Summary)
+	}
+}
+
+func TestReconcileReportsAmountDifferenceWithoutReference(t *testing.T) {
+	exact := newReconcileTransaction("", "Alice", 1000, domain.TransactionStatusSuccess, reconcileDay(1, 9))
+	off := newReconcileTransaction("", "Alice", 1000, domain.TransactionStatusSuccess, reconcileDay(1, 10))
+	service, _ := newTestReconciliationService(t, exact, off)
+
+	// The 999 row is closer to exact, but the 1000 row agrees on the amount,
+	// so it takes exact and the 999 row is paired with off.
+	short := newReconcileTransaction("", "Alice", 999, domain.TransactionStatusSuccess, reconcileDay(1, 9))
+	full := newReconcileTransaction("", "Alice", 1000, domain.TransactionStatusSuccess, reconcileDay(1, 8))
+	report, err := service.Reconcile(statementRows(short, full), DefaultReconcileTolerance)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if report.Summary != (domain.ReconciliationSummary{Matched: 1, Mismatched: 1}) {
+		t.Fatalf("Expected one match and one mismatch, got %+v", report.Summary)
+	}
+	if report.Matched[0].Stored.ID != exact.ID {
+		t.Errorf("Expected the 1000 row to match the exact transaction, got %+v", report.Matched[0].Stored)
+	}
+	mismatch := report.Mismatched[0]
+	if mismatch.Stored.ID != off.ID || mismatch.MatchedBy != domain.MatchByDetails || !slices.Equal(mismatch.Differences, []string{"amount"}) {
+		t.Errorf("Expected the 999 row to differ from the other transaction in amount, got %+v", mismatch)
+	}
+}
+
+func TestReconcileReportsUnmatchedRows(t *testing.T) {
+	matched := newReconcileTransaction("", "Alice", 1000, domain.TransactionStatusSuccess, reconcileDay(2, 9))
+	storedOnly := newReconcileTransaction("", "Bob", 500, domain.TransactionStatusSuccess, reconcileDay(3, 9))
+	outside := newReconcileTransaction("", "Carol", 700, domain.TransactionStatusSuccess, reconcileDay(20, 9))
+	service, _ := newTestReconciliationService(t, matched, storedOnly, outside)
+
+	statementOnly := newReconcileTransaction("", "Dave", 300, domain.TransactionStatusSuccess, reconcileDay(4, 9))
+	report, err := service.Reconcile(statementRows(matched, statementOnly), DefaultReconcileTolerance)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(report.StatementOnly) != 1 || report.StatementOnly[0].Line != 3 {
+		t.Errorf("Expected line 3 to be statement only, got %+v", report.StatementOnly)
+	}
+	if len(report.StoredOnly) != 1 || report.StoredOnly[0].ID != storedOnly.ID {
+		t.Errorf("Expected only Bob within the statement period to be stored only, got %+v", report.StoredOnly)
+	}
+	if !report.From.Equal(reconcileDay(2, 9)) || !report.To.Equal(reconcileDay(4, 9)) {
+		t.Errorf("Expected the period June 2nd to 4th, got %v to %v", report.From, report.To)
+	}
+}
+
+func TestReconcileLeavesStoreUnchanged(t *testing.T) {
+	stored := newReconcileTransaction("REF-1", "Alice", 1000, domain.TransactionStatusPending, reconcileDay(1, 9))
+	service, repo := newTestReconciliationService(t, stored)
+
+	statement := newReconcileTransaction("REF-1", "Alice", 1000, domain.TransactionStatusSuccess, reconcileDay(1, 9))
+	extra := newReconcileTransaction("", "Bob", 500, domain.TransactionStatusSuccess, reconcileDay(1, 10))
+	if _, err := service.Reconcile(statementRows(statement, extra), DefaultReconcileTolerance); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	transactions := repo.GetTransactions()
+	if len(transactions) != 1 || transactions[0].Status != domain.TransactionStatusPending {
+		t.Errorf("Expected the store to be unchanged, got %+v", transactions)
+	}
+}
+
+func TestReconcileRejectsNegativeTolerance(t *testing.T) {
+	service, _ := newTestReconciliationService(t)
+
+	if _, err := service.Reconcile(nil, -time.Hour); !errors.Is(err, ErrInvalidTolerance) {
+		t.Errorf("Expected ErrInvalidTolerance, got %v", err)
+	}
+}
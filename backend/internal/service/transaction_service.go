@@ -1,69 +1,877 @@
 package service
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"flip-test/internal/domain"
+	"flip-test/internal/fx"
 	"flip-test/internal/repository"
 	"fmt"
+	"log"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRatesNotConfigured  = errors.New("FX rates are not configured")
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrInvalidCursor       = errors.New("invalid cursor")
+	ErrTooManyBuckets      = errors.New("too many buckets")
+
+	ErrTransactionNotFound     = repository.ErrTransactionNotFound
+	ErrInvalidStatus           = errors.New("invalid status")
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+	ErrStatusReasonRequired    = errors.New("status change reason is required")
+
+	ErrInvalidAgingBuckets = errors.New("invalid aging buckets")
+	ErrInvalidExpiryAge    = errors.New("expiry age must be at least 1 day")
+
+	ErrInvalidReversal = errors.New("invalid reversal")
+	ErrAlreadyReversed = errors.New("transaction has already been reversed")
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+	// MaxSeriesBuckets bounds the size of a balance series response.
+	MaxSeriesBuckets = 1000
 )
 
 type TransactionService struct {
-	TransactionRepository *repository.TransactionRepository
+	TransactionStore repository.TransactionStore
+	RateTable        *fx.RateTable
+	// OverdraftFloor is the default minimum balance for overdraft detection.
+	OverdraftFloor int64
+	// AgingBuckets are the default upper bounds, in days, of the pending
+	// aging buckets.
+	AgingBuckets []int
+	// PendingExpiryDays is the default age after which PENDING transactions
+	// expire. Zero disables expiry unless a run passes its own age.
+	PendingExpiryDays int
 }
 
-func NewTransactionService(tr *repository.TransactionRepository) *TransactionService {
+// ExpiryActor is recorded as ChangedBy on transactions failed by expiry.
+const ExpiryActor = "system:expiry"
+
+// DefaultAgingBuckets makes the buckets 0-2, 3-7, 8-30 and over 30 days.
+var DefaultAgingBuckets = []int{2, 7, 30}
+
+func NewTransactionService(store repository.TransactionStore) *TransactionService {
 	return &TransactionService{
-		TransactionRepository: tr,
+		TransactionStore: store,
+		AgingBuckets:     DefaultAgingBuckets,
 	}
 }
 
-func (ts *TransactionService) SaveTransactions(transactions []domain.Transaction) error {
+func (ts *TransactionService) SaveTransactions(transactions []domain.Transaction) (domain.SaveResult, error) {
 	for i, transaction := range transactions {
 		if transaction.Amount <= 0 {
-			return fmt.Errorf("invalid amount at row %d: amount must be greater than 0", i+1)
+			return domain.SaveResult{}, fmt.Errorf("invalid amount at row %d: amount must be greater than 0", i+1)
 		}
 
 		if strings.TrimSpace(transaction.Name) == "" {
-			return fmt.Errorf("invalid name at row %d: name cannot be empty", i+1)
+			return domain.SaveResult{}, fmt.Errorf("invalid name at row %d: name cannot be empty", i+1)
 		}
 	}
 
-	ts.TransactionRepository.SaveTransactions(transactions)
-	return nil
+	claimed, err := ts.claimReversals(transactions)
+	if err != nil {
+		return domain.SaveResult{}, err
+	}
+
+	result, err := ts.TransactionStore.SaveTransactions(transactions)
+	if err != nil {
+		ts.releaseReversals(rowsAt(transactions, claimed))
+		return domain.SaveResult{}, err
+	}
+
+	// A reversal whose ID belongs to a different stored transaction is
+	// reported as a conflict and not saved, so its original must not stay
+	// linked to it.
+	unsaved := make([]int, 0)
+	for _, i := range claimed {
+		if outcome := result.Outcomes[i]; outcome != domain.SaveOutcomeInserted && outcome != domain.SaveOutcomeDuplicate {
+			unsaved = append(unsaved, i)
+		}
+	}
+	ts.releaseReversals(rowsAt(transactions, unsaved))
+
+	return result, nil
 }
 
-func (ts TransactionService) GetBalance() int64 {
-	var balance int64 = 0
-	transactions := ts.TransactionRepository.GetTransactions()
+// claimReversals validates every reversal in the batch against its original
+// and links the original to it through ReversedBy. Originals in the same
+// batch are linked in place; stored originals are linked atomically in the
+// store, so two concurrent uploads cannot reverse the same transaction. A
+// FAILED reversal is validated but never linked. It returns the rows of the
+// reversals it linked; on error every link made so far is undone.
+func (ts *TransactionService) claimReversals(transactions []domain.Transaction) ([]int, error) {
+	batch := make(map[uuid.UUID]int, len(transactions))
+	for i, transaction := range transactions {
+		batch[transaction.ID] = i
+	}
 
-	for _, transaction := range transactions {
-		if transaction.Status != domain.TransactionStatusSuccess {
+	claimed := make([]int, 0)
+	for i, reversal := range transactions {
+		if !reversal.IsReversal() {
+			continue
+		}
+
+		linked, err := ts.claimReversal(transactions, batch, reversal)
+		if err != nil {
+			ts.releaseReversals(rowsAt(transactions, claimed))
+			return nil, fmt.Errorf("%w at row %d", err, i+1)
+		}
+		if linked {
+			claimed = append(claimed, i)
+		}
+	}
+
+	return claimed, nil
+}
+
+func (ts *TransactionService) claimReversal(transactions []domain.Transaction, batch map[uuid.UUID]int, reversal domain.Transaction) (bool, error) {
+	if original, ok := ts.TransactionStore.GetTransaction(reversal.ReversalOf); ok {
+		if err := validateReversal(original, reversal); err != nil {
+			return false, err
+		}
+		if reversal.Status == domain.TransactionStatusFailed || original.ReversedBy == reversal.ID {
+			return false, nil
+		}
+
+		_, err := ts.TransactionStore.UpdateTransaction(original.ID, func(original *domain.Transaction) error {
+			if err := validateReversal(*original, reversal); err != nil {
+				return err
+			}
+			original.ReversedBy = reversal.ID
+			return nil
+		})
+		return err == nil, err
+	}
+
+	i, ok := batch[reversal.ReversalOf]
+	if !ok {
+		return false, fmt.Errorf("%w: original transaction %s not found", ErrInvalidReversal, reversal.ReversalOf)
+	}
+	if err := validateReversal(transactions[i], reversal); err != nil {
+		return false, err
+	}
+	if reversal.Status == domain.TransactionStatusFailed {
+		return false, nil
+	}
+	transactions[i].ReversedBy = reversal.ID
+	return true, nil
+}
+
+// releaseReversals unlinks the originals of reversals that were not saved,
+// were removed or failed.
+func (ts *TransactionService) releaseReversals(reversals []domain.Transaction) {
+	for _, reversal := range reversals {
+		if !reversal.IsReversal() {
+			continue
+		}
+
+		_, err := ts.TransactionStore.UpdateTransaction(reversal.ReversalOf, func(original *domain.Transaction) error {
+			if original.ReversedBy == reversal.ID {
+				original.ReversedBy = uuid.Nil
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, ErrTransactionNotFound) {
+			log.Printf("Failed to unlink reversal %s from %s: %v", reversal.ID, reversal.ReversalOf, err)
+		}
+	}
+}
+
+func rowsAt(transactions []domain.Transaction, rows []int) []domain.Transaction {
+	selected := make([]domain.Transaction, len(rows))
+	for i, row := range rows {
+		selected[i] = transactions[row]
+	}
+	return selected
+}
+
+// validateReversal checks that reversal fully undoes a successful original:
+// opposite type, same amount, currency and counterparty, and not earlier.
+func validateReversal(original, reversal domain.Transaction) error {
+	switch {
+	case original.ReversedBy != uuid.Nil && original.ReversedBy != reversal.ID:
+		return fmt.Errorf("%w: %s by %s", ErrAlreadyReversed, original.ID, original.ReversedBy)
+	case original.ID == reversal.ID || original.IsReversal():
+		return fmt.Errorf("%w: a reversal cannot be reversed", ErrInvalidReversal)
+	case original.Status != domain.TransactionStatusSuccess:
+		return fmt.Errorf("%w: original transaction is %s, not SUCCESS", ErrInvalidReversal, original.Status)
+	case original.Type == reversal.Type:
+		return fmt.Errorf("%w: a %s must be reversed by the opposite type", ErrInvalidReversal, original.Type)
+	case original.Amount != reversal.Amount:
+		return fmt.Errorf("%w: amount %d does not match the original %d", ErrInvalidReversal, reversal.Amount, original.Amount)
+	case original.EffectiveCurrency() != reversal.EffectiveCurrency():
+		return fmt.Errorf("%w: currency %s does not match the original %s", ErrInvalidReversal, reversal.EffectiveCurrency(), original.EffectiveCurrency())
+	case domain.CounterpartyKey(original.Name) != domain.CounterpartyKey(reversal.Name):
+		return fmt.Errorf("%w: counterparty '%s' does not match the original '%s'", ErrInvalidReversal, reversal.Name, original.Name)
+	case reversal.TransactionDate.Before(original.TransactionDate):
+		return fmt.Errorf("%w: reversal is dated before the original", ErrInvalidReversal)
+	default:
+		return nil
+	}
+}
+
+// GetReversals returns every linked original and reversal pair, most recent
+// reversal first.
+func (ts TransactionService) GetReversals() []domain.ReversalPair {
+	pairs := make([]domain.ReversalPair, 0)
+	for _, transaction := range ts.TransactionStore.GetTransactions() {
+		if !transaction.IsReversal() {
 			continue
 		}
 
+		original, ok := ts.TransactionStore.GetTransaction(transaction.ReversalOf)
+		if !ok || original.ReversedBy != transaction.ID {
+			continue
+		}
+		pairs = append(pairs, domain.ReversalPair{Original: original, Reversal: transaction})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if !pairs[i].Reversal.TransactionDate.Equal(pairs[j].Reversal.TransactionDate) {
+			return pairs[i].Reversal.TransactionDate.After(pairs[j].Reversal.TransactionDate)
+		}
+		return pairs[i].Reversal.ID.String() < pairs[j].Reversal.ID.String()
+	})
+
+	return pairs
+}
+
+// GetBalance returns the net balance of successful transactions per
+// currency, read from the totals the store maintains on every write.
+func (ts TransactionService) GetBalance() map[domain.Currency]int64 {
+	balances := make(map[domain.Currency]int64)
+	for currency, byStatus := range ts.TransactionStore.GetBalanceSummary() {
+		if totals, ok := byStatus[domain.TransactionStatusSuccess]; ok {
+			balances[currency] = totals.Net
+		}
+	}
+
+	return balances
+}
+
+func (ts TransactionService) GetBalanceSummary() domain.BalanceSummary {
+	return ts.TransactionStore.GetBalanceSummary()
+}
+
+// GetBalanceAsOf returns the totals of successful transactions per currency
+// with a TransactionDate at or before asOf.
+func (ts TransactionService) GetBalanceAsOf(asOf time.Time) map[domain.Currency]domain.BalanceTotals {
+	return ts.successfulTotals(time.Time{}, asOf)
+}
+
+// GetPeriodBalance returns opening balance, movement and closing balance per
+// currency for [from, to]. Either bound may be zero to leave it open.
+func (ts TransactionService) GetPeriodBalance(from, to time.Time) []domain.PeriodBalance {
+	opening := make(map[domain.Currency]domain.BalanceTotals)
+	if !from.IsZero() {
+		opening = ts.successfulTotals(time.Time{}, from.Add(-time.Nanosecond))
+	}
+	movement := ts.successfulTotals(from, to)
+
+	currencies := make(map[domain.Currency]struct{})
+	for currency := range opening {
+		currencies[currency] = struct{}{}
+	}
+	for currency := range movement {
+		currencies[currency] = struct{}{}
+	}
+
+	result := make([]domain.PeriodBalance, 0, len(currencies))
+	for currency := range currencies {
+		minorUnits, _ := currency.MinorUnits()
+		period := movement[currency]
+		result = append(result, domain.PeriodBalance{
+			Currency:       currency,
+			MinorUnits:     minorUnits,
+			From:           from,
+			To:             to,
+			OpeningBalance: opening[currency].Net,
+			Credits:        period.Credits,
+			Debits:         period.Debits,
+			Movement:       period.Net,
+			ClosingBalance: opening[currency].Net + period.Net,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Currency < result[j].Currency
+	})
+
+	return result
+}
+
+// GetBalanceSeries groups successful transactions of one currency into
+// interval buckets in location, from the bucket containing from up to the
+// bucket containing to. A zero from starts at the first transaction and a
+// zero to ends now. Buckets without transactions are included with zeros.
+func (ts TransactionService) GetBalanceSeries(currency domain.Currency, interval domain.BucketInterval, location *time.Location, from, to time.Time) (domain.BalanceSeries, error) {
+	minorUnits, ok := currency.MinorUnits()
+	if !ok {
+		return domain.BalanceSeries{}, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
+	if location == nil {
+		location = time.UTC
+	}
+
+	series := domain.BalanceSeries{
+		Currency:   currency,
+		MinorUnits: minorUnits,
+		Interval:   interval,
+		Timezone:   location.String(),
+		Buckets:    make([]domain.BalanceBucket, 0),
+	}
+
+	filter := domain.TransactionFilter{
+		Statuses: []domain.TransactionStatus{domain.TransactionStatusSuccess},
+		Currency: currency,
+	}
+	if from.IsZero() {
+		first := ts.TransactionStore.ListTransactions(domain.TransactionQuery{Filter: filter, SortBy: domain.SortByTransactionDate, Limit: 1})
+		if len(first) == 0 {
+			return series, nil
+		}
+		from = first[0].TransactionDate
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+
+	start := interval.Truncate(from.In(location))
+	last := interval.Truncate(to.In(location))
+	for bucket := start; !bucket.After(last); bucket = interval.Next(bucket) {
+		if len(series.Buckets) == MaxSeriesBuckets {
+			return domain.BalanceSeries{}, fmt.Errorf("%w: at most %d %s buckets per request", ErrTooManyBuckets, MaxSeriesBuckets, interval)
+		}
+		series.Buckets = append(series.Buckets, domain.BalanceBucket{Start: bucket})
+	}
+	if len(series.Buckets) == 0 {
+		return series, nil
+	}
+
+	series.OpeningBalance = ts.successfulTotals(time.Time{}, start.Add(-time.Nanosecond))[currency].Net
+
+	filter.From = start
+	filter.To = interval.Next(last).Add(-time.Nanosecond)
+	transactions := ts.TransactionStore.ListTransactions(domain.TransactionQuery{Filter: filter, SortBy: domain.SortByTransactionDate})
+
+	balance := series.OpeningBalance
+	next := 0
+	for i := range series.Buckets {
+		bucket := &series.Buckets[i]
+		end := interval.Next(bucket.Start)
+		for ; next < len(transactions) && transactions[next].TransactionDate.Before(end); next++ {
+			transaction := transactions[next]
+			if transaction.Type == domain.TransactionTypeCredit {
+				bucket.Credits += transaction.Amount
+			} else {
+				bucket.Debits += transaction.Amount
+			}
+		}
+
+		bucket.Net = bucket.Credits - bucket.Debits
+		balance += bucket.Net
+		bucket.ClosingBalance = balance
+	}
+
+	return series, nil
+}
+
+// GetOverdrafts replays the successful transactions of one currency in
+// TransactionDate order and reports every period the running balance spent
+// below floor. Transactions sharing a timestamp apply credits first, so an
+// incoming and outgoing transfer booked at the same instant do not count as
+// an overdraft.
+func (ts TransactionService) GetOverdrafts(currency domain.Currency, floor int64) ([]domain.Overdraft, error) {
+	if _, ok := currency.MinorUnits(); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
+
+	transactions := ts.TransactionStore.ListTransactions(domain.TransactionQuery{
+		Filter: domain.TransactionFilter{
+			Statuses: []domain.TransactionStatus{domain.TransactionStatusSuccess},
+			Currency: currency,
+		},
+		SortBy: domain.SortByTransactionDate,
+	})
+	sort.SliceStable(transactions, func(i, j int) bool {
+		a, b := transactions[i], transactions[j]
+		if !a.TransactionDate.Equal(b.TransactionDate) {
+			return a.TransactionDate.Before(b.TransactionDate)
+		}
+		return a.Type == domain.TransactionTypeCredit && b.Type != domain.TransactionTypeCredit
+	})
+
+	overdrafts := make([]domain.Overdraft, 0)
+	var current *domain.Overdraft
+	var balance int64
+	for _, transaction := range transactions {
 		if transaction.Type == domain.TransactionTypeCredit {
 			balance += transaction.Amount
 		} else {
 			balance -= transaction.Amount
 		}
+
+		switch {
+		case current == nil && balance < floor:
+			current = &domain.Overdraft{
+				Currency:      currency,
+				Floor:         floor,
+				StartedAt:     transaction.TransactionDate,
+				Cause:         transaction,
+				LowestBalance: balance,
+				LowestAt:      transaction.TransactionDate,
+			}
+		case current != nil && balance < current.LowestBalance:
+			current.LowestBalance = balance
+			current.LowestAt = transaction.TransactionDate
+		case current != nil && balance >= floor:
+			recovery := transaction
+			endedAt := transaction.TransactionDate
+			current.Recovery = &recovery
+			current.EndedAt = &endedAt
+			overdrafts = append(overdrafts, finishOverdraft(*current, endedAt))
+			current = nil
+		}
+	}
+	if current != nil {
+		overdrafts = append(overdrafts, finishOverdraft(*current, time.Now()))
 	}
 
-	return balance
+	return overdrafts, nil
 }
 
-func (ts TransactionService) GetUnsuccessfulTransactions() []domain.Transaction {
-	failedTransactions := make([]domain.Transaction, 0)
-	transactions := ts.TransactionRepository.GetTransactions()
+func finishOverdraft(overdraft domain.Overdraft, until time.Time) domain.Overdraft {
+	overdraft.Depth = overdraft.Floor - overdraft.LowestBalance
+	overdraft.DurationSeconds = int64(until.Sub(overdraft.StartedAt) / time.Second)
+	return overdraft
+}
+
+// GetPendingAging groups PENDING transactions by their age at reference,
+// measured in whole days since TransactionDate. bounds are the inclusive
+// upper bounds of every bucket but the last, which is open-ended.
+// Transactions dated after reference count as 0 days old.
+func (ts TransactionService) GetPendingAging(reference time.Time, bounds []int) (domain.AgingReport, error) {
+	if err := validateAgingBuckets(bounds); err != nil {
+		return domain.AgingReport{}, err
+	}
+
+	report := domain.AgingReport{
+		ReferenceTime: reference,
+		Buckets:       make([]domain.AgingBucket, 0, len(bounds)+1),
+	}
+	minDays := 0
+	for _, bound := range bounds {
+		maxDays := bound
+		report.Buckets = append(report.Buckets, domain.AgingBucket{
+			Label:   fmt.Sprintf("%d-%d days", minDays, maxDays),
+			MinDays: minDays,
+			MaxDays: &maxDays,
+			Amounts: make(map[domain.Currency]int64),
+		})
+		minDays = bound + 1
+	}
+	report.Buckets = append(report.Buckets, domain.AgingBucket{
+		Label:   fmt.Sprintf("over %d days", minDays-1),
+		MinDays: minDays,
+		Amounts: make(map[domain.Currency]int64),
+	})
+
+	// Oldest first, so the first transaction of each bucket is its oldest.
+	pending := ts.TransactionStore.ListTransactions(domain.TransactionQuery{
+		Filter: domain.TransactionFilter{Statuses: []domain.TransactionStatus{domain.TransactionStatusPending}},
+		SortBy: domain.SortByTransactionDate,
+	})
+	for _, transaction := range pending {
+		days := max(int(reference.Sub(transaction.TransactionDate)/(24*time.Hour)), 0)
+		i := sort.Search(len(bounds), func(i int) bool { return days <= bounds[i] })
+
+		bucket := &report.Buckets[i]
+		bucket.Count++
+		bucket.Amounts[transaction.EffectiveCurrency()] += transaction.Amount
+		if bucket.Oldest == nil {
+			oldest := transaction
+			bucket.Oldest = &oldest
+		}
+	}
+
+	return report, nil
+}
+
+// ExpirePending moves PENDING transactions dated more than maxAgeDays before
+// now to FAILED, recording the reason in their status history. A dry run only
+// reports them. The run stops early, returning what it did so far, when ctx
+// is cancelled.
+func (ts TransactionService) ExpirePending(ctx context.Context, now time.Time, maxAgeDays int, dryRun bool) (domain.ExpiryResult, error) {
+	if maxAgeDays <= 0 {
+		return domain.ExpiryResult{}, ErrInvalidExpiryAge
+	}
+
+	result := domain.ExpiryResult{
+		DryRun:  dryRun,
+		Cutoff:  now.AddDate(0, 0, -maxAgeDays),
+		Expired: make([]domain.Transaction, 0),
+	}
+	stale := ts.TransactionStore.ListTransactions(domain.TransactionQuery{
+		Filter: domain.TransactionFilter{
+			Statuses: []domain.TransactionStatus{domain.TransactionStatusPending},
+			To:       result.Cutoff.Add(-time.Nanosecond),
+		},
+		SortBy: domain.SortByTransactionDate,
+	})
+	if dryRun {
+		result.Expired = stale
+		return result, nil
+	}
+
+	reason := fmt.Sprintf("Expired after more than %d days pending", maxAgeDays)
+	for _, transaction := range stale {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		updated, err := ts.UpdateStatus(transaction.ID, domain.TransactionStatusFailed, reason, ExpiryActor)
+		if errors.Is(err, ErrInvalidStatusTransition) || errors.Is(err, ErrTransactionNotFound) {
+			result.Skipped++
+			continue
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to expire transaction %s: %w", transaction.ID, err)
+		}
+		result.Expired = append(result.Expired, updated)
+	}
+
+	return result, nil
+}
+
+// ParseAgingBuckets reads comma-separated bucket upper bounds in days, such
+// as "2,7,30".
+func ParseAgingBuckets(value string) ([]int, error) {
+	bounds := make([]int, 0)
+	for _, part := range strings.Split(value, ",") {
+		bound, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("%w: '%s' is not a whole number of days", ErrInvalidAgingBuckets, part)
+		}
+		bounds = append(bounds, bound)
+	}
+
+	if err := validateAgingBuckets(bounds); err != nil {
+		return nil, err
+	}
+	return bounds, nil
+}
+
+func validateAgingBuckets(bounds []int) error {
+	if len(bounds) == 0 {
+		return fmt.Errorf("%w: at least one bound is required", ErrInvalidAgingBuckets)
+	}
+	for i, bound := range bounds {
+		if bound < 0 || (i > 0 && bound <= bounds[i-1]) {
+			return fmt.Errorf("%w: bounds must be non-negative and increasing", ErrInvalidAgingBuckets)
+		}
+	}
+	return nil
+}
+
+// successfulTotals sums successful transactions per currency within
+// [from, to], read through the status index.
+func (ts TransactionService) successfulTotals(from, to time.Time) map[domain.Currency]domain.BalanceTotals {
+	totals := make(map[domain.Currency]domain.BalanceTotals)
+	transactions := ts.TransactionStore.ListTransactions(domain.TransactionQuery{
+		Filter: domain.TransactionFilter{
+			Statuses: []domain.TransactionStatus{domain.TransactionStatusSuccess},
+			From:     from,
+			To:       to,
+		},
+		SortBy: domain.SortByTransactionDate,
+	})
+
 	for _, transaction := range transactions {
-		if transaction.Status != domain.TransactionStatusSuccess {
-			failedTransactions = append(failedTransactions, transaction)
+		currency := transaction.EffectiveCurrency()
+		total := totals[currency]
+		total.Apply(transaction, 1)
+		totals[currency] = total
+	}
+
+	return totals
+}
+
+// GetBalanceBreakdown splits the balance of one currency by status and type.
+func (ts TransactionService) GetBalanceBreakdown(currency domain.Currency) (domain.BalanceBreakdown, error) {
+	minorUnits, ok := currency.MinorUnits()
+	if !ok {
+		return domain.BalanceBreakdown{}, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
+
+	byStatus := ts.TransactionStore.GetBalanceSummary()[currency]
+	success := byStatus[domain.TransactionStatusSuccess]
+	pending := byStatus[domain.TransactionStatusPending]
+	failed := byStatus[domain.TransactionStatusFailed]
+
+	breakdown := domain.BalanceBreakdown{
+		Currency:          currency,
+		MinorUnits:        minorUnits,
+		SuccessfulCredits: success.Credits,
+		SuccessfulDebits:  success.Debits,
+		NetBalance:        success.Net,
+		PendingInflow:     pending.Credits,
+		PendingOutflow:    pending.Debits,
+		FailedCredits:     failed.Credits,
+		FailedDebits:      failed.Debits,
+		ProjectedBalance:  success.Net + pending.Net,
+		Counts:            make(map[domain.TransactionStatus]int, len(domain.TransactionStatuses)),
+	}
+	for _, status := range domain.TransactionStatuses {
+		breakdown.Counts[status] = byStatus[status].Transactions
+	}
+
+	return breakdown, nil
+}
+
+// GetConsolidatedBalance converts every successful transaction into the
+// reporting currency at the rate of its own transaction date.
+func (ts TransactionService) GetConsolidatedBalance(reporting domain.Currency) (domain.ConsolidatedBalance, error) {
+	if ts.RateTable == nil {
+		return domain.ConsolidatedBalance{}, ErrRatesNotConfigured
+	}
+
+	minorUnits, ok := reporting.MinorUnits()
+	if !ok {
+		return domain.ConsolidatedBalance{}, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, reporting)
+	}
+
+	result := domain.ConsolidatedBalance{
+		Currency:     reporting,
+		MinorUnits:   minorUnits,
+		MissingRates: make([]domain.MissingRate, 0),
+	}
+	missing := make(map[domain.MissingRate]int)
+
+	for _, transaction := range ts.successfulTransactions() {
+		currency := transaction.EffectiveCurrency()
+		amount, ok, err := ts.RateTable.Convert(transaction.Amount, currency, reporting, transaction.TransactionDate)
+		if err != nil {
+			return domain.ConsolidatedBalance{}, fmt.Errorf("failed to convert transaction %s: %w", transaction.ID, err)
+		}
+		if !ok {
+			missing[domain.MissingRate{Currency: currency, Date: transaction.TransactionDate.UTC().Format("2006-01-02")}]++
+			continue
+		}
+
+		if transaction.Type == domain.TransactionTypeCredit {
+			result.Balance += amount
+		} else {
+			result.Balance -= amount
+		}
+	}
+
+	for rate, count := range missing {
+		rate.Transactions = count
+		result.MissingRates = append(result.MissingRates, rate)
+	}
+	sort.Slice(result.MissingRates, func(i, j int) bool {
+		if result.MissingRates[i].Currency != result.MissingRates[j].Currency {
+			return result.MissingRates[i].Currency < result.MissingRates[j].Currency
+		}
+		return result.MissingRates[i].Date < result.MissingRates[j].Date
+	})
+	result.Complete = len(result.MissingRates) == 0
+
+	return result, nil
+}
+
+func (ts TransactionService) GetTransaction(id uuid.UUID) (domain.Transaction, error) {
+	transaction, ok := ts.TransactionStore.GetTransaction(id)
+	if !ok {
+		return domain.Transaction{}, ErrTransactionNotFound
+	}
+	return transaction, nil
+}
+
+// UpdateStatus moves a transaction to status if the state machine allows it
+// and appends the change to its history. Balances and status queries see the
+// change as soon as it returns.
+func (ts TransactionService) UpdateStatus(id uuid.UUID, status domain.TransactionStatus, reason string, actor string) (domain.Transaction, error) {
+	if !status.IsValid() {
+		return domain.Transaction{}, fmt.Errorf("%w '%s'", ErrInvalidStatus, status)
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return domain.Transaction{}, ErrStatusReasonRequired
+	}
+
+	updated, err := ts.TransactionStore.UpdateTransaction(id, func(transaction *domain.Transaction) error {
+		if !transaction.Status.CanTransitionTo(status) {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, transaction.Status, status)
 		}
+
+		transaction.StatusHistory = append(transaction.StatusHistory, domain.StatusChange{
+			From:      transaction.Status,
+			To:        status,
+			Reason:    reason,
+			ChangedBy: actor,
+			ChangedAt: time.Now().UTC(),
+		})
+		transaction.Status = status
+		return nil
+	})
+	if err != nil {
+		return domain.Transaction{}, err
+	}
+
+	// A reversal that failed no longer undoes its original.
+	if updated.Status == domain.TransactionStatusFailed {
+		ts.releaseReversals([]domain.Transaction{updated})
 	}
 
-	sort.Slice(failedTransactions, func(i, j int) bool {
-		return failedTransactions[i].TransactionDate.After(failedTransactions[j].TransactionDate)
+	return updated, nil
+}
+
+func (ts TransactionService) GetUnsuccessfulTransactions() []domain.Transaction {
+	return ts.TransactionStore.ListTransactions(domain.TransactionQuery{
+		Filter: domain.TransactionFilter{
+			Statuses: []domain.TransactionStatus{domain.TransactionStatusFailed, domain.TransactionStatusPending},
+		},
+		SortBy:     domain.SortByTransactionDate,
+		Descending: true,
 	})
+}
+
+// successfulTransactions returns every SUCCESS transaction in date order,
+// read through the status index.
+func (ts TransactionService) successfulTransactions() []domain.Transaction {
+	return ts.TransactionStore.ListTransactions(domain.TransactionQuery{
+		Filter: domain.TransactionFilter{
+			Statuses: []domain.TransactionStatus{domain.TransactionStatusSuccess},
+		},
+		SortBy: domain.SortByTransactionDate,
+	})
+}
+
+// ListTransactions returns one page of transactions matching the query. The
+// cursor is the NextCursor of the previous page, or empty for the first one.
+func (ts TransactionService) ListTransactions(query domain.TransactionQuery, cursor string) (domain.TransactionPage, error) {
+	if query.SortBy == "" {
+		query.SortBy = domain.SortByTransactionDate
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultPageSize
+	}
+	if query.Limit > MaxPageSize {
+		query.Limit = MaxPageSize
+	}
+
+	if cursor != "" {
+		after, err := decodeCursor(cursor, query)
+		if err != nil {
+			return domain.TransactionPage{}, err
+		}
+		query.After = &after
+	}
+
+	// Ask for one extra transaction to learn whether another page exists.
+	limit := query.Limit
+	query.Limit++
+	transactions := ts.TransactionStore.ListTransactions(query)
+
+	page := domain.TransactionPage{Transactions: transactions}
+	if len(transactions) > limit {
+		page.Transactions = transactions[:limit]
+		page.NextCursor = encodeCursor(page.Transactions[limit-1], query)
+	}
+
+	return page, nil
+}
+
+type transactionCursor struct {
+	SortBy     domain.TransactionSortField `json:"s"`
+	Descending bool                        `json:"d"`
+	ID         uuid.UUID                   `json:"i"`
+	Value      json.RawMessage             `json:"v,omitempty"`
+}
+
+// encodeCursor keeps only what is needed to resume after the transaction:
+// its ID and the value of the sort field.
+func encodeCursor(last domain.Transaction, query domain.TransactionQuery) string {
+	var value any
+	switch query.SortBy {
+	case domain.SortByAmount:
+		value = last.Amount
+	case domain.SortByName:
+		value = last.Name
+	case domain.SortByType:
+		value = last.Type
+	case domain.SortByStatus:
+		value = last.Status
+	case domain.SortByCurrency:
+		value = last.EffectiveCurrency()
+	case domain.SortByDescription:
+		value = last.Description
+	case domain.SortByReference:
+		value = last.Reference
+	case domain.SortByTransactionDate:
+		value = last.TransactionDate
+	}
+
+	cursor := transactionCursor{SortBy: query.SortBy, Descending: query.Descending, ID: last.ID}
+	if value != nil {
+		cursor.Value, _ = json.Marshal(value)
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string, query domain.TransactionQuery) (domain.Transaction, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return domain.Transaction{}, ErrInvalidCursor
+	}
+
+	var cursor transactionCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return domain.Transaction{}, ErrInvalidCursor
+	}
+	if cursor.SortBy != query.SortBy || cursor.Descending != query.Descending {
+		return domain.Transaction{}, fmt.Errorf("%w: cursor was created for a different sort order", ErrInvalidCursor)
+	}
+
+	after := domain.Transaction{ID: cursor.ID}
+	var target any
+	switch cursor.SortBy {
+	case domain.SortByAmount:
+		target = &after.Amount
+	case domain.SortByName:
+		target = &after.Name
+	case domain.SortByType:
+		target = &after.Type
+	case domain.SortByStatus:
+		target = &after.Status
+	case domain.SortByCurrency:
+		target = &after.Currency
+	case domain.SortByDescription:
+		target = &after.Description
+	case domain.SortByReference:
+		target = &after.Reference
+	case domain.SortByTransactionDate:
+		target = &after.TransactionDate
+	}
+	if target != nil {
+		if err := json.Unmarshal(cursor.Value, target); err != nil {
+			return domain.Transaction{}, ErrInvalidCursor
+		}
+	}
 
-	return failedTransactions
+	return after, nil
 }
@@ -1,8 +1,13 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"flip-test/internal/domain"
+	"flip-test/internal/fx"
 	"flip-test/internal/repository"
+	"fmt"
+	"math/big"
 	"strings"
 	"testing"
 	"time"
@@ -10,6 +15,19 @@ import (
 	"github.com/google/uuid"
 )
 
+// seedTransactions saves transactions through a TransactionService over a
+// new in-memory repository, the starting point of most service tests.
+func seedTransactions(t *testing.T, transactions ...domain.Transaction) (*TransactionService, *repository.TransactionRepository) {
+	t.Helper()
+
+	repo := repository.NewTransactionRepository()
+	service := NewTransactionService(repo)
+	if _, err := service.SaveTransactions(transactions); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	return service, repo
+}
+
 func TestSaveTransactions_ValidTransactions(t *testing.T) {
 	repo := repository.NewTransactionRepository()
 	service := NewTransactionService(repo)
@@ -24,7 +42,7 @@ func TestSaveTransactions_ValidTransactions(t *testing.T) {
 		},
 	}
 
-	err := service.SaveTransactions(transactions)
+	_, err := service.SaveTransactions(transactions)
 
 	if err != nil {
 		t.Errorf("Expected no error, got: %v", err)
@@ -49,7 +67,7 @@ func TestSaveTransactions_InvalidAmount(t *testing.T) {
 		},
 	}
 
-	err := service.SaveTransactions(transactions)
+	_, err := service.SaveTransactions(transactions)
 
 	if err == nil {
 		t.Error("Expected error for zero amount, got none")
@@ -73,7 +91,7 @@ func TestSaveTransactions_NegativeAmount(t *testing.T) {
 		},
 	}
 
-	err := service.SaveTransactions(transactions)
+	_, err := service.SaveTransactions(transactions)
 
 	if err == nil {
 		t.Error("Expected error for negative amount, got none")
@@ -93,7 +111,7 @@ func TestSaveTransactions_EmptyName(t *testing.T) {
 		},
 	}
 
-	err := service.SaveTransactions(transactions)
+	_, err := service.SaveTransactions(transactions)
 
 	if err == nil {
 		t.Error("Expected error for empty name, got none")
@@ -117,7 +135,7 @@ func TestSaveTransactions_WhitespaceName(t *testing.T) {
 		},
 	}
 
-	err := service.SaveTransactions(transactions)
+	_, err := service.SaveTransactions(transactions)
 
 	if err == nil {
 		t.Error("Expected error for whitespace-only name, got none")
@@ -130,8 +148,8 @@ func TestGetBalance_EmptyTransactions(t *testing.T) {
 
 	balance := service.GetBalance()
 
-	if balance != 0 {
-		t.Errorf("Expected balance 0, got %d", balance)
+	if len(balance) != 0 {
+		t.Errorf("Expected no balances, got %v", balance)
 	}
 }
 
@@ -157,7 +175,7 @@ func TestGetBalance_OnlyCreditTransactions(t *testing.T) {
 	}
 
 	repo.SaveTransactions(transactions)
-	balance := service.GetBalance()
+	balance := service.GetBalance()[domain.DefaultCurrency]
 
 	expected := int64(1500000)
 	if balance != expected {
@@ -187,7 +205,7 @@ func TestGetBalance_OnlyDebitTransactions(t *testing.T) {
 	}
 
 	repo.SaveTransactions(transactions)
-	balance := service.GetBalance()
+	balance := service.GetBalance()[domain.DefaultCurrency]
 
 	expected := int64(-500000)
 	if balance != expected {
@@ -217,7 +235,7 @@ func TestGetBalance_MixedTransactions(t *testing.T) {
 	}
 
 	repo.SaveTransactions(transactions)
-	balance := service.GetBalance()
+	balance := service.GetBalance()[domain.DefaultCurrency]
 
 	expected := int64(700000) // 1000000 - 300000
 	if balance != expected {
@@ -247,7 +265,7 @@ func TestGetBalance_IgnoresFailedTransactions(t *testing.T) {
 	}
 
 	repo.SaveTransactions(transactions)
-	balance := service.GetBalance()
+	balance := service.GetBalance()[domain.DefaultCurrency]
 
 	expected := int64(1000000) // Failed transaction should not affect balance
 	if balance != expected {
@@ -277,7 +295,7 @@ func TestGetBalance_IgnoresPendingTransactions(t *testing.T) {
 	}
 
 	repo.SaveTransactions(transactions)
-	balance := service.GetBalance()
+	balance := service.GetBalance()[domain.DefaultCurrency]
 
 	expected := int64(1000000) // Pending transaction should not affect balance
 	if balance != expected {
@@ -285,6 +303,58 @@ func TestGetBalance_IgnoresPendingTransactions(t *testing.T) {
 	}
 }
 
+func TestGetBalance_PerCurrency(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	service := NewTransactionService(repo)
+
+	transactions := []domain.Transaction{
+		{
+			ID:       uuid.New(),
+			Name:     "Rupiah Credit",
+			Type:     domain.TransactionTypeCredit,
+			Amount:   1000000,
+			Currency: "IDR",
+			Status:   domain.TransactionStatusSuccess,
+		},
+		{
+			ID:       uuid.New(),
+			Name:     "Dollar Credit",
+			Type:     domain.TransactionTypeCredit,
+			Amount:   15000,
+			Currency: "USD",
+			Status:   domain.TransactionStatusSuccess,
+		},
+		{
+			ID:       uuid.New(),
+			Name:     "Dollar Debit",
+			Type:     domain.TransactionTypeDebit,
+			Amount:   2550,
+			Currency: "USD",
+			Status:   domain.TransactionStatusSuccess,
+		},
+		{
+			ID:     uuid.New(),
+			Name:   "Legacy Debit",
+			Type:   domain.TransactionTypeDebit,
+			Amount: 200000,
+			Status: domain.TransactionStatusSuccess,
+		},
+	}
+
+	repo.SaveTransactions(transactions)
+	balance := service.GetBalance()
+
+	if len(balance) != 2 {
+		t.Fatalf("Expected 2 currency balances, got %v", balance)
+	}
+	if balance["IDR"] != 800000 {
+		t.Errorf("Expected IDR balance 800000, got %d", balance["IDR"])
+	}
+	if balance["USD"] != 12450 {
+		t.Errorf("Expected USD balance 12450, got %d", balance["USD"])
+	}
+}
+
 func TestGetUnsuccessfulTransactions_EmptyRepository(t *testing.T) {
 	repo := repository.NewTransactionRepository()
 	service := NewTransactionService(repo)
@@ -418,3 +488,836 @@ func TestGetUnsuccessfulTransactions_SortedByDateDescending(t *testing.T) {
 		}
 	}
 }
+
+func TestGetConsolidatedBalance_ReportsMissingRates(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	service := NewTransactionService(repo)
+
+	jan1 := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	service.RateTable = fx.NewRateTable([]fx.Rate{
+		{Date: jan1, From: "USD", To: "IDR", Value: big.NewRat(15000, 1)},
+	})
+
+	transactions := []domain.Transaction{
+		{
+			ID:              uuid.New(),
+			Name:            "Rupiah Credit",
+			Type:            domain.TransactionTypeCredit,
+			Amount:          100000000,
+			Currency:        "IDR",
+			Status:          domain.TransactionStatusSuccess,
+			TransactionDate: jan1,
+		},
+		{
+			ID:              uuid.New(),
+			Name:            "Dollar Debit",
+			Type:            domain.TransactionTypeDebit,
+			Amount:          1000,
+			Currency:        "USD",
+			Status:          domain.TransactionStatusSuccess,
+			TransactionDate: jan1,
+		},
+		{
+			ID:              uuid.New(),
+			Name:            "Singapore Credit",
+			Type:            domain.TransactionTypeCredit,
+			Amount:          500,
+			Currency:        "SGD",
+			Status:          domain.TransactionStatusSuccess,
+			TransactionDate: jan1,
+		},
+	}
+	repo.SaveTransactions(transactions)
+
+	balance, err := service.GetConsolidatedBalance("IDR")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := int64(85000000) // 1,000,000 IDR - 10 USD * 15,000, in sen
+	if balance.Balance != expected {
+		t.Errorf("Expected consolidated balance %d, got %d", expected, balance.Balance)
+	}
+
+	if balance.Complete || len(balance.MissingRates) != 1 {
+		t.Fatalf("Expected one missing rate, got %+v", balance.MissingRates)
+	}
+	if balance.MissingRates[0].Currency != "SGD" || balance.MissingRates[0].Date != "2024-01-01" {
+		t.Errorf("Expected missing SGD rate on 2024-01-01, got %+v", balance.MissingRates[0])
+	}
+}
+
+func TestGetConsolidatedBalance_WithoutRates(t *testing.T) {
+	service := NewTransactionService(repository.NewTransactionRepository())
+
+	if _, err := service.GetConsolidatedBalance("IDR"); err != ErrRatesNotConfigured {
+		t.Errorf("Expected ErrRatesNotConfigured, got: %v", err)
+	}
+}
+
+func TestSaveTransactions_ReportsDuplicatesAndConflicts(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	service := NewTransactionService(repo)
+
+	original := domain.Transaction{
+		Reference: "TRX-001",
+		Name:      "John Doe",
+		Type:      domain.TransactionTypeCredit,
+		Amount:    1000000,
+		Status:    domain.TransactionStatusPending,
+	}
+	original.ID = domain.NewTransactionID(original)
+
+	other := domain.Transaction{
+		Name:   "Jane Smith",
+		Type:   domain.TransactionTypeDebit,
+		Amount: 250000,
+		Status: domain.TransactionStatusSuccess,
+	}
+	other.ID = domain.NewTransactionID(other)
+
+	result, err := service.SaveTransactions([]domain.Transaction{original, other})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if result.Inserted != 2 {
+		t.Errorf("Expected 2 inserted, got %+v", result)
+	}
+
+	changed := original
+	changed.Status = domain.TransactionStatusSuccess
+
+	result, err = service.SaveTransactions([]domain.Transaction{other, changed})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if result.Inserted != 0 || result.Duplicates != 1 || result.Conflicts != 1 {
+		t.Errorf("Expected 1 duplicate and 1 conflict, got %+v", result)
+	}
+	if result.Outcomes[0] != domain.SaveOutcomeDuplicate || result.Outcomes[1] != domain.SaveOutcomeConflict {
+		t.Errorf("Expected outcomes in input order, got %v", result.Outcomes)
+	}
+
+	if len(repo.GetTransactions()) != 2 {
+		t.Errorf("Expected re-upload not to add transactions, got %d", len(repo.GetTransactions()))
+	}
+
+	balance := service.GetBalance()[domain.DefaultCurrency]
+	if balance != -250000 {
+		t.Errorf("Expected conflicting row not to overwrite stored one, got balance %d", balance)
+	}
+}
+
+var listBase = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// listTransactions returns ten transactions an hour apart from listBase,
+// cycling through statuses and three counterparties.
+func listTransactions() []domain.Transaction {
+	statuses := []domain.TransactionStatus{
+		domain.TransactionStatusSuccess,
+		domain.TransactionStatusPending,
+		domain.TransactionStatusFailed,
+	}
+
+	var transactions []domain.Transaction
+	for i := 0; i < 10; i++ {
+		transactionType := domain.TransactionTypeCredit
+		if i%2 == 1 {
+			transactionType = domain.TransactionTypeDebit
+		}
+		transactions = append(transactions, domain.Transaction{
+			ID:              uuid.New(),
+			Name:            fmt.Sprintf("Counterparty %d", i%3),
+			Type:            transactionType,
+			Amount:          int64(100 * (i + 1)),
+			Status:          statuses[i%3],
+			TransactionDate: listBase.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	return transactions
+}
+
+func TestListTransactions_PaginatesWithCursor(t *testing.T) {
+	service, _ := seedTransactions(t, listTransactions()...)
+
+	query := domain.TransactionQuery{SortBy: domain.SortByAmount, Descending: true, Limit: 4}
+
+	var amounts []int64
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("Expected pagination to end")
+		}
+
+		page, err := service.ListTransactions(query, cursor)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		for _, transaction := range page.Transactions {
+			amounts = append(amounts, transaction.Amount)
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	if len(amounts) != 10 {
+		t.Fatalf("Expected 10 transactions across pages, got %d", len(amounts))
+	}
+	for i := 0; i < len(amounts)-1; i++ {
+		if amounts[i] <= amounts[i+1] {
+			t.Fatalf("Expected amounts in descending order, got %v", amounts)
+		}
+	}
+}
+
+func TestListTransactions_Filters(t *testing.T) {
+	service, _ := seedTransactions(t, listTransactions()...)
+
+	minAmount := int64(300)
+	query := domain.TransactionQuery{
+		Filter: domain.TransactionFilter{
+			Statuses:  []domain.TransactionStatus{domain.TransactionStatusSuccess, domain.TransactionStatusPending},
+			Types:     []domain.TransactionType{domain.TransactionTypeCredit},
+			Name:      "counterparty",
+			MinAmount: &minAmount,
+			From:      listBase.Add(2 * time.Hour),
+			To:        listBase.Add(8 * time.Hour),
+		},
+	}
+
+	page, err := service.ListTransactions(query, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// Hours 2..8 that are CREDIT (even) and SUCCESS or PENDING (i%3 != 2): 4 and 6.
+	if len(page.Transactions) != 2 {
+		t.Fatalf("Expected 2 transactions, got %+v", page.Transactions)
+	}
+	if page.Transactions[0].Amount != 500 || page.Transactions[1].Amount != 700 {
+		t.Errorf("Expected amounts 500 and 700 in date order, got %d and %d", page.Transactions[0].Amount, page.Transactions[1].Amount)
+	}
+	if page.NextCursor != "" {
+		t.Errorf("Expected no next cursor, got %s", page.NextCursor)
+	}
+}
+
+func TestListTransactions_InvalidCursor(t *testing.T) {
+	service, _ := seedTransactions(t, listTransactions()...)
+
+	page, err := service.ListTransactions(domain.TransactionQuery{SortBy: domain.SortByName, Limit: 2}, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if _, err := service.ListTransactions(domain.TransactionQuery{SortBy: domain.SortByAmount, Limit: 2}, page.NextCursor); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor for a different sort order, got: %v", err)
+	}
+	if _, err := service.ListTransactions(domain.TransactionQuery{}, "not-a-cursor!"); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor for garbage, got: %v", err)
+	}
+}
+
+func TestListTransactions_DateOrderedPagesByStatus(t *testing.T) {
+	service, _ := seedTransactions(t, listTransactions()...)
+
+	for _, descending := range []bool{false, true} {
+		query := domain.TransactionQuery{
+			Filter:     domain.TransactionFilter{Statuses: []domain.TransactionStatus{domain.TransactionStatusSuccess}},
+			SortBy:     domain.SortByTransactionDate,
+			Descending: descending,
+			Limit:      3,
+		}
+
+		first, err := service.ListTransactions(query, "")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		second, err := service.ListTransactions(query, first.NextCursor)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		// SUCCESS rows are hours 0, 3, 6 and 9.
+		if len(first.Transactions) != 3 || len(second.Transactions) != 1 || second.NextCursor != "" {
+			t.Fatalf("Expected pages of 3 and 1, got %d and %d", len(first.Transactions), len(second.Transactions))
+		}
+
+		all := append(first.Transactions, second.Transactions...)
+		for i := 0; i < len(all)-1; i++ {
+			if all[i].TransactionDate.Before(all[i+1].TransactionDate) == descending {
+				t.Fatalf("Expected date order (descending=%v), got %v then %v", descending, all[i].TransactionDate, all[i+1].TransactionDate)
+			}
+		}
+	}
+}
+
+func TestGetBalanceBreakdown(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	service := NewTransactionService(repo)
+
+	transactions := []domain.Transaction{
+		{ID: uuid.New(), Name: "A", Type: domain.TransactionTypeCredit, Amount: 10000, Status: domain.TransactionStatusSuccess},
+		{ID: uuid.New(), Name: "B", Type: domain.TransactionTypeDebit, Amount: 3000, Status: domain.TransactionStatusSuccess},
+		{ID: uuid.New(), Name: "C", Type: domain.TransactionTypeCredit, Amount: 2000, Status: domain.TransactionStatusPending},
+		{ID: uuid.New(), Name: "D", Type: domain.TransactionTypeDebit, Amount: 500, Status: domain.TransactionStatusPending},
+		{ID: uuid.New(), Name: "E", Type: domain.TransactionTypeDebit, Amount: 800, Status: domain.TransactionStatusFailed},
+		{ID: uuid.New(), Name: "F", Type: domain.TransactionTypeCredit, Amount: 9000, Currency: "USD", Status: domain.TransactionStatusSuccess},
+	}
+	if _, err := repo.SaveTransactions(transactions); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	breakdown, err := service.GetBalanceBreakdown(domain.DefaultCurrency)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if breakdown.SuccessfulCredits != 10000 || breakdown.SuccessfulDebits != 3000 || breakdown.NetBalance != 7000 {
+		t.Errorf("Expected successful 10000/3000/7000, got %+v", breakdown)
+	}
+	if breakdown.PendingInflow != 2000 || breakdown.PendingOutflow != 500 {
+		t.Errorf("Expected pending 2000/500, got %+v", breakdown)
+	}
+	if breakdown.FailedCredits != 0 || breakdown.FailedDebits != 800 {
+		t.Errorf("Expected failed 0/800, got %+v", breakdown)
+	}
+	if breakdown.ProjectedBalance != 8500 {
+		t.Errorf("Expected projected balance 8500, got %d", breakdown.ProjectedBalance)
+	}
+	expectedCounts := map[domain.TransactionStatus]int{
+		domain.TransactionStatusSuccess: 2,
+		domain.TransactionStatusPending: 2,
+		domain.TransactionStatusFailed:  1,
+	}
+	for status, count := range expectedCounts {
+		if breakdown.Counts[status] != count {
+			t.Errorf("Expected %d %s transactions, got %d", count, status, breakdown.Counts[status])
+		}
+	}
+}
+
+func TestGetBalanceBreakdown_UnsupportedCurrency(t *testing.T) {
+	service := NewTransactionService(repository.NewTransactionRepository())
+
+	if _, err := service.GetBalanceBreakdown("XYZ"); !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("Expected ErrUnsupportedCurrency, got: %v", err)
+	}
+}
+
+func monthlyTransactions() []domain.Transaction {
+	return []domain.Transaction{
+		{ID: uuid.New(), Name: "Jan credit", Type: domain.TransactionTypeCredit, Amount: 10000, Status: domain.TransactionStatusSuccess,
+			TransactionDate: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{ID: uuid.New(), Name: "Jan close", Type: domain.TransactionTypeDebit, Amount: 1000, Status: domain.TransactionStatusSuccess,
+			TransactionDate: time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC)},
+		{ID: uuid.New(), Name: "Feb credit", Type: domain.TransactionTypeCredit, Amount: 4000, Status: domain.TransactionStatusSuccess,
+			TransactionDate: time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC)},
+		{ID: uuid.New(), Name: "Feb debit", Type: domain.TransactionTypeDebit, Amount: 1500, Status: domain.TransactionStatusSuccess,
+			TransactionDate: time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC)},
+		{ID: uuid.New(), Name: "Feb pending", Type: domain.TransactionTypeCredit, Amount: 9999, Status: domain.TransactionStatusPending,
+			TransactionDate: time.Date(2024, 2, 21, 0, 0, 0, 0, time.UTC)},
+	}
+}
+
+func TestGetBalanceAsOf_IncludesTheInstantItself(t *testing.T) {
+	service, _ := seedTransactions(t, monthlyTransactions()...)
+
+	balance := service.GetBalanceAsOf(time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC))[domain.DefaultCurrency]
+	if balance.Net != 9000 {
+		t.Errorf("Expected balance 9000 at the end of January, got %d", balance.Net)
+	}
+
+	if balances := service.GetBalanceAsOf(time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)); len(balances) != 0 {
+		t.Errorf("Expected no balance before the first transaction, got %v", balances)
+	}
+}
+
+func TestGetPeriodBalance(t *testing.T) {
+	service, _ := seedTransactions(t, monthlyTransactions()...)
+
+	periods := service.GetPeriodBalance(
+		time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 29, 23, 59, 59, 0, time.UTC),
+	)
+	if len(periods) != 1 {
+		t.Fatalf("Expected 1 currency, got %d", len(periods))
+	}
+
+	period := periods[0]
+	if period.OpeningBalance != 9000 || period.Credits != 4000 || period.Debits != 1500 ||
+		period.Movement != 2500 || period.ClosingBalance != 11500 {
+		t.Errorf("Expected opening 9000, movement 2500 and closing 11500, got %+v", period)
+	}
+}
+
+func TestGetBalanceSeries_FillsEmptyBuckets(t *testing.T) {
+	service, _ := seedTransactions(t, monthlyTransactions()...)
+
+	series, err := service.GetBalanceSeries(domain.DefaultCurrency, domain.BucketMonth, time.UTC,
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := []domain.BalanceBucket{
+		{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Credits: 10000, Debits: 1000, Net: 9000, ClosingBalance: 9000},
+		{Start: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Credits: 4000, Debits: 1500, Net: 2500, ClosingBalance: 11500},
+		{Start: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), ClosingBalance: 11500},
+	}
+	if len(series.Buckets) != len(expected) {
+		t.Fatalf("Expected %d buckets, got %d", len(expected), len(series.Buckets))
+	}
+	for i, bucket := range series.Buckets {
+		if !bucket.Start.Equal(expected[i].Start) || bucket.Credits != expected[i].Credits || bucket.Debits != expected[i].Debits ||
+			bucket.Net != expected[i].Net || bucket.ClosingBalance != expected[i].ClosingBalance {
+			t.Errorf("Bucket %d: expected %+v, got %+v", i, expected[i], bucket)
+		}
+	}
+}
+
+func TestGetBalanceSeries_BucketsInTimeZone(t *testing.T) {
+	service, _ := seedTransactions(t, monthlyTransactions()...)
+
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+
+	// 2024-01-31 23:59:59 UTC is already February 1st in Jakarta.
+	series, err := service.GetBalanceSeries(domain.DefaultCurrency, domain.BucketDay, jakarta,
+		time.Date(2024, 1, 31, 0, 0, 0, 0, jakarta), time.Date(2024, 2, 1, 0, 0, 0, 0, jakarta))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(series.Buckets) != 2 {
+		t.Fatalf("Expected 2 buckets, got %d", len(series.Buckets))
+	}
+	if series.OpeningBalance != 10000 || series.Buckets[0].Net != 0 || series.Buckets[1].Debits != 1000 || series.Buckets[1].ClosingBalance != 9000 {
+		t.Errorf("Expected the January 31st debit in the February 1st bucket, got opening %d and %+v", series.OpeningBalance, series.Buckets)
+	}
+}
+
+func TestGetBalanceSeries_WeeksStartOnMonday(t *testing.T) {
+	// 2024-02-21 is a Wednesday.
+	start := domain.BucketWeek.Truncate(time.Date(2024, 2, 21, 15, 0, 0, 0, time.UTC))
+	if !start.Equal(time.Date(2024, 2, 19, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected week to start on 2024-02-19, got %v", start)
+	}
+}
+
+func TestGetBalanceSeries_TooManyBuckets(t *testing.T) {
+	service := NewTransactionService(repository.NewTransactionRepository())
+
+	_, err := service.GetBalanceSeries(domain.DefaultCurrency, domain.BucketDay, time.UTC,
+		time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if !errors.Is(err, ErrTooManyBuckets) {
+		t.Errorf("Expected ErrTooManyBuckets, got: %v", err)
+	}
+}
+
+func TestGetOverdrafts(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	service := NewTransactionService(repo)
+
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	cause := domain.Transaction{ID: uuid.New(), Name: "Rent", Type: domain.TransactionTypeDebit, Amount: 8000, Status: domain.TransactionStatusSuccess, TransactionDate: day(2)}
+	recovery := domain.Transaction{ID: uuid.New(), Name: "Salary", Type: domain.TransactionTypeCredit, Amount: 10000, Status: domain.TransactionStatusSuccess, TransactionDate: day(5)}
+	transactions := []domain.Transaction{
+		{ID: uuid.New(), Name: "Opening", Type: domain.TransactionTypeCredit, Amount: 5000, Status: domain.TransactionStatusSuccess, TransactionDate: day(1)},
+		cause,
+		{ID: uuid.New(), Name: "Groceries", Type: domain.TransactionTypeDebit, Amount: 1000, Status: domain.TransactionStatusSuccess, TransactionDate: day(3)},
+		{ID: uuid.New(), Name: "Ignored", Type: domain.TransactionTypeDebit, Amount: 99999, Status: domain.TransactionStatusFailed, TransactionDate: day(4)},
+		recovery,
+		// Same instant: the credit is applied first, so this is no overdraft.
+		{ID: uuid.New(), Name: "Out", Type: domain.TransactionTypeDebit, Amount: 6000, Status: domain.TransactionStatusSuccess, TransactionDate: day(6)},
+		{ID: uuid.New(), Name: "In", Type: domain.TransactionTypeCredit, Amount: 6000, Status: domain.TransactionStatusSuccess, TransactionDate: day(6)},
+	}
+	if _, err := repo.SaveTransactions(transactions); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	overdrafts, err := service.GetOverdrafts(domain.DefaultCurrency, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(overdrafts) != 1 {
+		t.Fatalf("Expected 1 overdraft, got %d: %+v", len(overdrafts), overdrafts)
+	}
+
+	overdraft := overdrafts[0]
+	if overdraft.Cause.ID != cause.ID || overdraft.Recovery == nil || overdraft.Recovery.ID != recovery.ID {
+		t.Errorf("Expected cause %s and recovery %s, got %+v", cause.ID, recovery.ID, overdraft)
+	}
+	if overdraft.LowestBalance != -4000 || overdraft.Depth != 4000 || !overdraft.LowestAt.Equal(day(3)) {
+		t.Errorf("Expected lowest balance -4000 on day 3, got %d on %v", overdraft.LowestBalance, overdraft.LowestAt)
+	}
+	if overdraft.DurationSeconds != int64(3*24*time.Hour/time.Second) {
+		t.Errorf("Expected a 3 day overdraft, got %d seconds", overdraft.DurationSeconds)
+	}
+}
+
+func TestGetOverdrafts_FloorAndOngoing(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	service := NewTransactionService(repo)
+
+	transactions := []domain.Transaction{
+		{ID: uuid.New(), Name: "Opening", Type: domain.TransactionTypeCredit, Amount: 5000, Status: domain.TransactionStatusSuccess,
+			TransactionDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: uuid.New(), Name: "Transfer", Type: domain.TransactionTypeDebit, Amount: 2000, Status: domain.TransactionStatusSuccess,
+			TransactionDate: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	if _, err := repo.SaveTransactions(transactions); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if overdrafts, _ := service.GetOverdrafts(domain.DefaultCurrency, 0); len(overdrafts) != 0 {
+		t.Errorf("Expected no overdraft against a zero floor, got %+v", overdrafts)
+	}
+
+	overdrafts, err := service.GetOverdrafts(domain.DefaultCurrency, 4000)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(overdrafts) != 1 || overdrafts[0].EndedAt != nil || overdrafts[0].Depth != 1000 {
+		t.Errorf("Expected one ongoing overdraft 1000 below the floor, got %+v", overdrafts)
+	}
+}
+
+func TestUpdateStatus_SettlesPendingTransaction(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	service := NewTransactionService(repo)
+
+	pending := domain.Transaction{ID: uuid.New(), Name: "Transfer", Type: domain.TransactionTypeCredit, Amount: 2500,
+		Status: domain.TransactionStatusPending, TransactionDate: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+	if _, err := repo.SaveTransactions([]domain.Transaction{pending}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	updated, err := service.UpdateStatus(pending.ID, domain.TransactionStatusSuccess, "Confirmed by bank", "finance")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if updated.Status != domain.TransactionStatusSuccess || len(updated.StatusHistory) != 1 {
+		t.Fatalf("Expected SUCCESS with one history entry, got %+v", updated)
+	}
+	change := updated.StatusHistory[0]
+	if change.From != domain.TransactionStatusPending || change.Reason != "Confirmed by bank" || change.ChangedBy != "finance" || change.ChangedAt.IsZero() {
+		t.Errorf("Unexpected status change: %+v", change)
+	}
+
+	if balance := service.GetBalance()[domain.DefaultCurrency]; balance != 2500 {
+		t.Errorf("Expected balance 2500 after settlement, got %d", balance)
+	}
+	if issues := service.GetUnsuccessfulTransactions(); len(issues) != 0 {
+		t.Errorf("Expected no issues after settlement, got %d", len(issues))
+	}
+
+	// Re-importing the original PENDING row is still a duplicate.
+	result, err := repo.SaveTransactions([]domain.Transaction{pending})
+	if err != nil || result.Duplicates != 1 {
+		t.Errorf("Expected the original row to be a duplicate, got %+v (%v)", result, err)
+	}
+}
+
+func TestUpdateStatus_EnforcesStateMachine(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	service := NewTransactionService(repo)
+
+	failed := domain.Transaction{ID: uuid.New(), Name: "Refund", Type: domain.TransactionTypeDebit, Amount: 100, Status: domain.TransactionStatusFailed}
+	pending := domain.Transaction{ID: uuid.New(), Name: "Payout", Type: domain.TransactionTypeDebit, Amount: 100, Status: domain.TransactionStatusPending}
+	if _, err := repo.SaveTransactions([]domain.Transaction{failed, pending}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	tests := []struct {
+		id       uuid.UUID
+		status   domain.TransactionStatus
+		reason   string
+		expected error
+	}{
+		{failed.ID, domain.TransactionStatusSuccess, "Retry", ErrInvalidStatusTransition},
+		{pending.ID, domain.TransactionStatusPending, "No-op", ErrInvalidStatusTransition},
+		{pending.ID, "SETTLED", "Typo", ErrInvalidStatus},
+		{pending.ID, domain.TransactionStatusFailed, "  ", ErrStatusReasonRequired},
+		{uuid.New(), domain.TransactionStatusFailed, "Unknown", ErrTransactionNotFound},
+	}
+	for _, test := range tests {
+		if _, err := service.UpdateStatus(test.id, test.status, test.reason, "ops"); !errors.Is(err, test.expected) {
+			t.Errorf("UpdateStatus(%s, %q): expected %v, got %v", test.status, test.reason, test.expected, err)
+		}
+	}
+
+	if stored, _ := repo.GetTransaction(pending.ID); stored.Status != domain.TransactionStatusPending || len(stored.StatusHistory) != 0 {
+		t.Errorf("Expected rejected changes to leave the transaction untouched, got %+v", stored)
+	}
+}
+
+func newReversalFixture(t *testing.T) (*TransactionService, domain.Transaction) {
+	t.Helper()
+
+	original := domain.Transaction{ID: uuid.New(), Name: "Shop", Type: domain.TransactionTypeDebit, Amount: 4000,
+		Status: domain.TransactionStatusSuccess, TransactionDate: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)}
+	service, _ := seedTransactions(t, original)
+	return service, original
+}
+
+func newReversal(original domain.Transaction) domain.Transaction {
+	return domain.Transaction{ID: uuid.New(), Name: "SHOP", Type: domain.TransactionTypeCredit, Amount: original.Amount,
+		Status: domain.TransactionStatusSuccess, TransactionDate: original.TransactionDate.AddDate(0, 0, 3), ReversalOf: original.ID}
+}
+
+func TestSaveTransactions_LinksReversalToOriginal(t *testing.T) {
+	service, original := newReversalFixture(t)
+	reversal := newReversal(original)
+
+	if _, err := service.SaveTransactions([]domain.Transaction{reversal}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	stored, _ := service.GetTransaction(original.ID)
+	if stored.ReversedBy != reversal.ID || stored.Status != domain.TransactionStatusSuccess {
+		t.Errorf("Expected the original to stay SUCCESS and link to the reversal, got %+v", stored)
+	}
+	if balance := service.GetBalance()[domain.DefaultCurrency]; balance != 0 {
+		t.Errorf("Expected the pair to net to 0, got %d", balance)
+	}
+	if before := service.GetBalanceAsOf(original.TransactionDate)[domain.DefaultCurrency]; before.Net != -4000 {
+		t.Errorf("Expected the original debit before the reversal date, got %d", before.Net)
+	}
+
+	pairs := service.GetReversals()
+	if len(pairs) != 1 || pairs[0].Original.ID != original.ID || pairs[0].Reversal.ID != reversal.ID {
+		t.Errorf("Expected one reversal pair, got %+v", pairs)
+	}
+
+	// Re-importing the same reversal is a duplicate, not a second reversal.
+	result, err := service.SaveTransactions([]domain.Transaction{reversal})
+	if err != nil || result.Duplicates != 1 {
+		t.Errorf("Expected a duplicate, got %+v (%v)", result, err)
+	}
+}
+
+func TestSaveTransactions_RejectsSecondReversal(t *testing.T) {
+	service, original := newReversalFixture(t)
+
+	if _, err := service.SaveTransactions([]domain.Transaction{newReversal(original)}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := service.SaveTransactions([]domain.Transaction{newReversal(original)}); !errors.Is(err, ErrAlreadyReversed) {
+		t.Errorf("Expected ErrAlreadyReversed, got: %v", err)
+	}
+}
+
+func TestSaveTransactions_ValidatesReversal(t *testing.T) {
+	service, original := newReversalFixture(t)
+
+	wrongAmount := newReversal(original)
+	wrongAmount.Amount = 3999
+	wrongName := newReversal(original)
+	wrongName.Name = "Other shop"
+	sameType := newReversal(original)
+	sameType.Type = domain.TransactionTypeDebit
+	unknown := newReversal(original)
+	unknown.ReversalOf = uuid.New()
+
+	for _, reversal := range []domain.Transaction{wrongAmount, wrongName, sameType, unknown} {
+		if _, err := service.SaveTransactions([]domain.Transaction{reversal}); !errors.Is(err, ErrInvalidReversal) {
+			t.Errorf("Expected ErrInvalidReversal for %+v, got: %v", reversal, err)
+		}
+	}
+
+	if stored, _ := service.GetTransaction(original.ID); stored.ReversedBy != uuid.Nil {
+		t.Errorf("Expected rejected reversals to leave the original unlinked, got %+v", stored)
+	}
+}
+
+func TestSaveTransactions_ConflictingReversalReleasesOriginal(t *testing.T) {
+	service, original := newReversalFixture(t)
+	unrelated := domain.Transaction{ID: uuid.New(), Name: "Other", Type: domain.TransactionTypeCredit, Amount: 10,
+		Status: domain.TransactionStatusSuccess, TransactionDate: original.TransactionDate}
+	if _, err := service.SaveTransactions([]domain.Transaction{unrelated}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// The reversal reuses the ID of the unrelated transaction, as a reused
+	// reference would, so it is not saved.
+	reversal := newReversal(original)
+	reversal.ID = unrelated.ID
+	result, err := service.SaveTransactions([]domain.Transaction{reversal})
+	if err != nil || result.Conflicts != 1 {
+		t.Fatalf("Expected a conflict, got %+v (%v)", result, err)
+	}
+
+	if stored, _ := service.GetTransaction(original.ID); stored.ReversedBy != uuid.Nil {
+		t.Errorf("Expected the conflicting reversal to leave the original unlinked, got %+v", stored)
+	}
+	if _, err := service.SaveTransactions([]domain.Transaction{newReversal(original)}); err != nil {
+		t.Errorf("Expected a new reversal to be accepted, got: %v", err)
+	}
+	if pairs := service.GetReversals(); len(pairs) != 1 {
+		t.Errorf("Expected one reversal pair, got %+v", pairs)
+	}
+}
+
+func TestUpdateStatus_FailedReversalReleasesOriginal(t *testing.T) {
+	service, original := newReversalFixture(t)
+	reversal := newReversal(original)
+	reversal.Status = domain.TransactionStatusPending
+
+	if _, err := service.SaveTransactions([]domain.Transaction{reversal}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := service.UpdateStatus(reversal.ID, domain.TransactionStatusFailed, "Refund bounced", "ops"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if stored, _ := service.GetTransaction(original.ID); stored.ReversedBy != uuid.Nil {
+		t.Errorf("Expected the failed reversal to unlink the original, got %+v", stored)
+	}
+	if _, err := service.SaveTransactions([]domain.Transaction{newReversal(original)}); err != nil {
+		t.Errorf("Expected a new reversal to be accepted, got: %v", err)
+	}
+}
+
+func TestGetPendingAging(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	service := NewTransactionService(repo)
+
+	reference := time.Date(2024, 9, 30, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return reference.Add(-time.Duration(days) * 24 * time.Hour) }
+	pending := func(days int, amount int64, currency domain.Currency) domain.Transaction {
+		return domain.Transaction{ID: uuid.New(), Name: "P", Type: domain.TransactionTypeDebit, Amount: amount, Currency: currency,
+			Status: domain.TransactionStatusPending, TransactionDate: daysAgo(days)}
+	}
+	transactions := []domain.Transaction{
+		pending(0, 100, ""),
+		pending(2, 200, ""),
+		pending(3, 300, "USD"),
+		pending(8, 400, ""),
+		pending(45, 500, ""),
+		pending(31, 600, ""),
+		{ID: uuid.New(), Name: "Done", Type: domain.TransactionTypeDebit, Amount: 999, Status: domain.TransactionStatusSuccess, TransactionDate: daysAgo(60)},
+	}
+	if _, err := repo.SaveTransactions(transactions); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	report, err := service.GetPendingAging(reference, DefaultAgingBuckets)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := []struct {
+		label  string
+		count  int
+		idr    int64
+		oldest int64
+	}{
+		{"0-2 days", 2, 300, 200},
+		{"3-7 days", 1, 0, 300},
+		{"8-30 days", 1, 400, 400},
+		{"over 30 days", 2, 1100, 500},
+	}
+	if len(report.Buckets) != len(expected) {
+		t.Fatalf("Expected %d buckets, got %d", len(expected), len(report.Buckets))
+	}
+	for i, bucket := range report.Buckets {
+		want := expected[i]
+		if bucket.Label != want.label || bucket.Count != want.count || bucket.Amounts[domain.DefaultCurrency] != want.idr {
+			t.Errorf("Bucket %d: expected %+v, got %+v", i, want, bucket)
+		}
+		if bucket.Oldest == nil || bucket.Oldest.Amount != want.oldest {
+			t.Errorf("Bucket %d: expected oldest amount %d, got %+v", i, want.oldest, bucket.Oldest)
+		}
+	}
+	if report.Buckets[1].Amounts["USD"] != 300 {
+		t.Errorf("Expected USD amounts kept apart, got %v", report.Buckets[1].Amounts)
+	}
+}
+
+func TestParseAgingBuckets(t *testing.T) {
+	if bounds, err := ParseAgingBuckets("1, 14,60"); err != nil || len(bounds) != 3 || bounds[1] != 14 {
+		t.Errorf("Expected [1 14 60], got %v (%v)", bounds, err)
+	}
+
+	for _, value := range []string{"", "7,2", "1,1", "-1,5", "a,b"} {
+		if _, err := ParseAgingBuckets(value); !errors.Is(err, ErrInvalidAgingBuckets) {
+			t.Errorf("Expected ErrInvalidAgingBuckets for '%s', got: %v", value, err)
+		}
+	}
+}
+
+func seedStalePending(t *testing.T, now time.Time) (*TransactionService, domain.Transaction, domain.Transaction) {
+	t.Helper()
+
+	stale := domain.Transaction{ID: uuid.New(), Name: "Stale", Type: domain.TransactionTypeDebit, Amount: 100,
+		Status: domain.TransactionStatusPending, TransactionDate: now.AddDate(0, 0, -31)}
+	fresh := domain.Transaction{ID: uuid.New(), Name: "Fresh", Type: domain.TransactionTypeDebit, Amount: 100,
+		Status: domain.TransactionStatusPending, TransactionDate: now.AddDate(0, 0, -29)}
+	service, _ := seedTransactions(t, stale, fresh)
+	return service, stale, fresh
+}
+
+func TestExpirePending_FailsStaleTransactions(t *testing.T) {
+	now := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	service, stale, fresh := seedStalePending(t, now)
+
+	result, err := service.ExpirePending(context.Background(), now, 30, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(result.Expired) != 1 || result.Expired[0].ID != stale.ID {
+		t.Fatalf("Expected only the stale transaction to expire, got %+v", result.Expired)
+	}
+
+	expired, _ := service.GetTransaction(stale.ID)
+	if expired.Status != domain.TransactionStatusFailed || len(expired.StatusHistory) != 1 ||
+		expired.StatusHistory[0].ChangedBy != ExpiryActor || !strings.Contains(expired.StatusHistory[0].Reason, "30 days") {
+		t.Errorf("Expected a FAILED transaction with the expiry recorded, got %+v", expired)
+	}
+	if kept, _ := service.GetTransaction(fresh.ID); kept.Status != domain.TransactionStatusPending {
+		t.Errorf("Expected the fresh transaction to stay PENDING, got %s", kept.Status)
+	}
+
+	again, err := service.ExpirePending(context.Background(), now, 30, false)
+	if err != nil || len(again.Expired) != 0 {
+		t.Errorf("Expected a second run to find nothing, got %+v (%v)", again, err)
+	}
+}
+
+func TestExpirePending_DryRun(t *testing.T) {
+	now := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	service, stale, _ := seedStalePending(t, now)
+
+	result, err := service.ExpirePending(context.Background(), now, 30, true)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !result.DryRun || len(result.Expired) != 1 {
+		t.Fatalf("Expected a dry run reporting one transaction, got %+v", result)
+	}
+	if unchanged, _ := service.GetTransaction(stale.ID); unchanged.Status != domain.TransactionStatusPending {
+		t.Errorf("Expected a dry run to change nothing, got %s", unchanged.Status)
+	}
+}
+
+func TestExpirePending_StopsWhenCancelled(t *testing.T) {
+	now := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	service, stale, _ := seedStalePending(t, now)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := service.ExpirePending(ctx, now, 30, false); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+	if unchanged, _ := service.GetTransaction(stale.ID); unchanged.Status != domain.TransactionStatusPending {
+		t.Errorf("Expected a cancelled run to change nothing, got %s", unchanged.Status)
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"flip-test/internal/domain"
+	"flip-test/internal/repository"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUploadNotFound         = errors.New("upload not found")
+	ErrUploadRolledBack       = errors.New("upload has already been rolled back")
+	ErrRollbackReasonRequired = errors.New("rollback reason is required")
+	ErrUploadReversed         = repository.ErrUploadReversed
+)
+
+type UploadService struct {
+	UploadStore        repository.UploadStore
+	TransactionService *TransactionService
+	rollbackMutex      sync.Mutex
+}
+
+func NewUploadService(store repository.UploadStore, ts *TransactionService) *UploadService {
+	return &UploadService{
+		UploadStore:        store,
+		TransactionService: ts,
+	}
+}
+
+// Import records the upload as importing, links the transactions to it and
+// saves them, then completes the record with its row counts. Recording the
+// upload first means a stored transaction always belongs to a known upload
+// that can be rolled back, even when the import stops halfway. rejected is
+// the number of rows the parser skipped.
+func (us *UploadService) Import(upload domain.Upload, transactions []domain.Transaction, rejected int) (domain.Upload, error) {
+	upload.Status = domain.UploadStatusImporting
+	if err := us.UploadStore.SaveUpload(upload); err != nil {
+		return domain.Upload{}, fmt.Errorf("failed to record upload %s: %w", upload.ID, err)
+	}
+
+	for i := range transactions {
+		transactions[i].UploadID = upload.ID
+	}
+
+	result, err := us.TransactionService.SaveTransactions(transactions)
+	if err != nil {
+		upload.Status = domain.UploadStatusFailed
+		if saveErr := us.UploadStore.SaveUpload(upload); saveErr != nil {
+			log.Printf("Failed to mark upload %s as failed: %v", upload.ID, saveErr)
+		}
+		return domain.Upload{}, err
+	}
+
+	upload.Status = domain.UploadStatusCompleted
+	upload.TotalRows = len(transactions) + rejected
+	upload.Inserted = result.Inserted
+	upload.Duplicates = result.Duplicates
+	upload.Conflicts = result.Conflicts
+	upload.Rejected = rejected
+
+	if err := us.UploadStore.SaveUpload(upload); err != nil {
+		return domain.Upload{}, fmt.Errorf("failed to complete upload %s: %w", upload.ID, err)
+	}
+	return upload, nil
+}
+
+func (us *UploadService) GetUploads() []domain.Upload {
+	return us.UploadStore.GetUploads()
+}
+
+// GetUpload returns the upload and the transactions it inserted, oldest first.
+func (us *UploadService) GetUpload(id uuid.UUID) (domain.Upload, []domain.Transaction, error) {
+	upload, ok := us.UploadStore.GetUpload(id)
+	if !ok {
+		return domain.Upload{}, nil, ErrUploadNotFound
+	}
+
+	transactions := us.TransactionService.TransactionStore.GetTransactionsByUpload(id)
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].TransactionDate.Before(transactions[j].TransactionDate)
+	})
+
+	return upload, transactions, nil
+}
+
+// Rollback removes every transaction imported by the upload and keeps who
+// rolled it back and why on the upload record. An upload holding originals
+// reversed from another upload cannot be rolled back until those reversals
+// are, since they would be left undoing a missing transaction.
+func (us *UploadService) Rollback(id uuid.UUID, actor string, reason string) (domain.Upload, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return domain.Upload{}, ErrRollbackReasonRequired
+	}
+
+	us.rollbackMutex.Lock()
+	defer us.rollbackMutex.Unlock()
+
+	upload, ok := us.UploadStore.GetUpload(id)
+	if !ok {
+		return domain.Upload{}, ErrUploadNotFound
+	}
+	if upload.Rollback != nil {
+		return domain.Upload{}, ErrUploadRolledBack
+	}
+
+	removed, err := us.TransactionService.TransactionStore.DeleteTransactionsByUpload(id)
+	if err != nil {
+		return domain.Upload{}, err
+	}
+	us.TransactionService.releaseReversals(removed)
+
+	upload.Rollback = &domain.UploadRollback{
+		RolledBackBy:        actor,
+		RolledBackAt:        time.Now().UTC(),
+		Reason:              reason,
+		TransactionsRemoved: len(removed),
+	}
+	if err := us.UploadStore.SaveUpload(upload); err != nil {
+		return domain.Upload{}, fmt.Errorf("failed to record rollback of upload %s: %w", upload.ID, err)
+	}
+
+	return upload, nil
+}
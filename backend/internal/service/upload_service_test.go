@@ -0,0 +1,247 @@
+package service
+
+import (
+	"errors"
+	"flip-test/internal/domain"
+	"flip-test/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestUploadService() *UploadService {
+	transactionService := NewTransactionService(repository.NewTransactionRepository())
+	return NewUploadService(repository.NewUploadRepository(), transactionService)
+}
+
+func TestImport_RecordsUploadAndLinksTransactions(t *testing.T) {
+	service := newTestUploadService()
+
+	now := time.Now()
+	transactions := []domain.Transaction{
+		{
+			ID:              uuid.New(),
+			Name:            "Later",
+			Type:            domain.TransactionTypeCredit,
+			Amount:          1000000,
+			Status:          domain.TransactionStatusSuccess,
+			TransactionDate: now,
+		},
+		{
+			ID:              uuid.New(),
+			Name:            "Earlier",
+			Type:            domain.TransactionTypeDebit,
+			Amount:          250000,
+			Status:          domain.TransactionStatusFailed,
+			TransactionDate: now.Add(-time.Hour),
+		},
+	}
+
+	upload, err := service.Import(domain.Upload{
+		ID:         uuid.New(),
+		Filename:   "statement.csv",
+		UploadedBy: "ops",
+		UploadedAt: now,
+	}, transactions, 3)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if upload.TotalRows != 5 || upload.Inserted != 2 || upload.Rejected != 3 {
+		t.Errorf("Unexpected upload counts: %+v", upload)
+	}
+
+	stored, linked, err := service.GetUpload(upload.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if stored.Filename != "statement.csv" || stored.UploadedBy != "ops" {
+		t.Errorf("Unexpected stored upload: %+v", stored)
+	}
+
+	if len(linked) != 2 {
+		t.Fatalf("Expected 2 linked transactions, got %d", len(linked))
+	}
+	if linked[0].Name != "Earlier" || linked[0].UploadID != upload.ID {
+		t.Errorf("Expected linked transactions oldest first, got %+v", linked[0])
+	}
+}
+
+// unavailableUploadStore refuses to record uploads.
+type unavailableUploadStore struct {
+	*repository.UploadRepository
+}
+
+func (unavailableUploadStore) SaveUpload(domain.Upload) error {
+	return errors.New("disk full")
+}
+
+func TestImport_RecordsUploadBeforeTransactions(t *testing.T) {
+	service := newTestUploadService()
+
+	upload, err := service.Import(domain.Upload{ID: uuid.New()}, nil, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if upload.Status != domain.UploadStatusCompleted {
+		t.Errorf("Expected a completed upload, got %s", upload.Status)
+	}
+
+	transactionService := NewTransactionService(repository.NewTransactionRepository())
+	service = NewUploadService(unavailableUploadStore{repository.NewUploadRepository()}, transactionService)
+	transaction := domain.Transaction{ID: uuid.New(), Name: "Alice", Type: domain.TransactionTypeCredit, Amount: 1000, Status: domain.TransactionStatusSuccess}
+	if _, err := service.Import(domain.Upload{ID: uuid.New()}, []domain.Transaction{transaction}, 0); err == nil {
+		t.Fatal("Expected an error when the upload cannot be recorded")
+	}
+	if stored := transactionService.TransactionStore.GetTransactions(); len(stored) != 0 {
+		t.Errorf("Expected no transactions without an upload record, got %d", len(stored))
+	}
+}
+
+func TestImport_DuplicatesStayLinkedToFirstUpload(t *testing.T) {
+	service := newTestUploadService()
+
+	transaction := domain.Transaction{
+		ID:     uuid.New(),
+		Name:   "John Doe",
+		Type:   domain.TransactionTypeCredit,
+		Amount: 1000000,
+		Status: domain.TransactionStatusSuccess,
+	}
+
+	first, _ := service.Import(domain.Upload{ID: uuid.New(), UploadedAt: time.Now()}, []domain.Transaction{transaction}, 0)
+	second, _ := service.Import(domain.Upload{ID: uuid.New(), UploadedAt: time.Now().Add(time.Second)}, []domain.Transaction{transaction}, 0)
+
+	if second.Duplicates != 1 || second.Inserted != 0 {
+		t.Errorf("Expected second upload to report a duplicate, got %+v", second)
+	}
+
+	_, linked, _ := service.GetUpload(second.ID)
+	if len(linked) != 0 {
+		t.Errorf("Expected no transactions linked to the second upload, got %d", len(linked))
+	}
+
+	uploads := service.GetUploads()
+	if len(uploads) != 2 || uploads[0].ID != second.ID || uploads[1].ID != first.ID {
+		t.Errorf("Expected uploads newest first, got %+v", uploads)
+	}
+}
+
+func TestGetUpload_NotFound(t *testing.T) {
+	service := newTestUploadService()
+
+	if _, _, err := service.GetUpload(uuid.New()); err != ErrUploadNotFound {
+		t.Errorf("Expected ErrUploadNotFound, got: %v", err)
+	}
+}
+
+func TestRollback_RemovesTransactionsAndRecordsAudit(t *testing.T) {
+	service := newTestUploadService()
+	transactionService := service.TransactionService
+
+	kept, _ := service.Import(domain.Upload{ID: uuid.New()}, []domain.Transaction{
+		{ID: uuid.New(), Name: "Kept", Type: domain.TransactionTypeCredit, Amount: 500000, Status: domain.TransactionStatusSuccess},
+	}, 0)
+	wrong, _ := service.Import(domain.Upload{ID: uuid.New()}, []domain.Transaction{
+		{ID: uuid.New(), Name: "Wrong Credit", Type: domain.TransactionTypeCredit, Amount: 1000000, Status: domain.TransactionStatusSuccess},
+		{ID: uuid.New(), Name: "Wrong Pending", Type: domain.TransactionTypeDebit, Amount: 300000, Status: domain.TransactionStatusPending},
+	}, 0)
+
+	upload, err := service.Rollback(wrong.ID, "ops", "partner sent the wrong file")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if upload.Rollback == nil || upload.Rollback.RolledBackBy != "ops" || upload.Rollback.TransactionsRemoved != 2 {
+		t.Fatalf("Unexpected rollback record: %+v", upload.Rollback)
+	}
+
+	if balance := transactionService.GetBalance()[domain.DefaultCurrency]; balance != 500000 {
+		t.Errorf("Expected balance 500000 after rollback, got %d", balance)
+	}
+	if issues := transactionService.GetUnsuccessfulTransactions(); len(issues) != 0 {
+		t.Errorf("Expected rolled back pending transaction to be removed, got %d issues", len(issues))
+	}
+
+	stored, _, _ := service.GetUpload(wrong.ID)
+	if stored.Rollback == nil || stored.Rollback.Reason != "partner sent the wrong file" {
+		t.Errorf("Expected rollback to be stored on the upload, got %+v", stored.Rollback)
+	}
+
+	if _, linked, _ := service.GetUpload(kept.ID); len(linked) != 1 {
+		t.Errorf("Expected other uploads to be untouched, got %d transactions", len(linked))
+	}
+}
+
+func TestRollback_Errors(t *testing.T) {
+	service := newTestUploadService()
+	upload, _ := service.Import(domain.Upload{ID: uuid.New()}, nil, 0)
+
+	if _, err := service.Rollback(upload.ID, "ops", "  "); err != ErrRollbackReasonRequired {
+		t.Errorf("Expected ErrRollbackReasonRequired, got: %v", err)
+	}
+	if _, err := service.Rollback(uuid.New(), "ops", "wrong file"); err != ErrUploadNotFound {
+		t.Errorf("Expected ErrUploadNotFound, got: %v", err)
+	}
+	if _, err := service.Rollback(upload.ID, "ops", "wrong file"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := service.Rollback(upload.ID, "ops", "wrong file"); err != ErrUploadRolledBack {
+		t.Errorf("Expected ErrUploadRolledBack, got: %v", err)
+	}
+}
+
+func TestRollback_UnlinksReversedOriginals(t *testing.T) {
+	service := newTestUploadService()
+
+	original := domain.Transaction{ID: uuid.New(), Name: "Shop", Type: domain.TransactionTypeDebit, Amount: 700, Status: domain.TransactionStatusSuccess}
+	if _, err := service.Import(domain.Upload{ID: uuid.New()}, []domain.Transaction{original}, 0); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	refunds, err := service.Import(domain.Upload{ID: uuid.New()}, []domain.Transaction{
+		{ID: uuid.New(), Name: "Shop", Type: domain.TransactionTypeCredit, Amount: 700, Status: domain.TransactionStatusSuccess, ReversalOf: original.ID},
+	}, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if _, err := service.Rollback(refunds.ID, "ops", "refund file was a test"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if stored, _ := service.TransactionService.GetTransaction(original.ID); stored.ReversedBy != uuid.Nil {
+		t.Errorf("Expected the rolled back refund to unlink the original, got %+v", stored)
+	}
+}
+
+func TestRollback_RefusesReversedOriginals(t *testing.T) {
+	service := newTestUploadService()
+
+	original := domain.Transaction{ID: uuid.New(), Name: "Shop", Type: domain.TransactionTypeDebit, Amount: 700, Status: domain.TransactionStatusSuccess}
+	originals, err := service.Import(domain.Upload{ID: uuid.New()}, []domain.Transaction{original}, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	refunds, err := service.Import(domain.Upload{ID: uuid.New()}, []domain.Transaction{
+		{ID: uuid.New(), Name: "Shop", Type: domain.TransactionTypeCredit, Amount: 700, Status: domain.TransactionStatusSuccess, ReversalOf: original.ID},
+	}, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if _, err := service.Rollback(originals.ID, "ops", "wrong file"); !errors.Is(err, ErrUploadReversed) {
+		t.Fatalf("Expected ErrUploadReversed, got: %v", err)
+	}
+	if _, err := service.TransactionService.GetTransaction(original.ID); err != nil {
+		t.Errorf("Expected the refused rollback to keep the original, got: %v", err)
+	}
+
+	// Once the refunds are rolled back the originals can be too.
+	if _, err := service.Rollback(refunds.ID, "ops", "wrong file"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := service.Rollback(originals.ID, "ops", "wrong file"); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+}
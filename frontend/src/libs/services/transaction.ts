@@ -1,10 +1,10 @@
 import { serviceErrorHandler } from '@/libs/helpers/error';
 import type { APIResponse } from '@/libs/types/apiResponse';
-import type { Transaction } from '@/libs/types/transaction';
+import type { CurrencyBalance, Transaction } from '@/libs/types/transaction';
 
 const API_URL = import.meta.env.VITE_API_URL || 'http://localhost:8080';
 
-export async function getBalance(): Promise<APIResponse<number>> {
+export async function getBalance(): Promise<APIResponse<CurrencyBalance[]>> {
   try {
     const response = await fetch(`${API_URL}/transactions/balance`, {
       method: 'GET',
@@ -7,7 +7,14 @@ export interface Transaction {
   name: string;
   type: TransactionType;
   amount: number;
+  currency: string;
   status: TransactionStatus;
   description: string;
   transaction_date: string;
 }
+
+export interface CurrencyBalance {
+  currency: string;
+  balance: number;
+  minor_units: number;
+}
@@ -38,7 +38,7 @@ const Transaction = () => {
         isLoading={uploadTransactionMutation.isPending}
       />
       <HeaderPage onClickUpload={() => setIsModalOpen(true)} />
-      <Balance balance={balanceQuery.data ?? 0} />
+      <Balance balances={balanceQuery.data ?? []} />
       <TransactionList transactions={unsuccessfulTransactionQuery.data ?? []} />
     </Container>
   );
@@ -1,22 +1,30 @@
 import cn from 'classnames';
+import type { CurrencyBalance } from '@/libs/types/transaction';
 import { formatCurrency } from '../../utils/helpers';
 import styles from './Balance.module.css';
 
 type BalanceProps = {
-  balance: number;
+  balances: CurrencyBalance[];
 };
 
-const Balance = ({ balance }: BalanceProps) => {
+const EMPTY_BALANCE: CurrencyBalance = { currency: 'IDR', balance: 0, minor_units: 0 };
+
+const Balance = ({ balances }: BalanceProps) => {
+  const items = balances.length > 0 ? balances : [EMPTY_BALANCE];
+
   return (
     <div className={styles.card}>
       <p className={styles.label}>Current Balance</p>
-      <div
-        className={cn(styles.balance, {
-          [styles.balance_negative]: balance < 0,
-        })}
-      >
-        {formatCurrency(balance)}
-      </div>
+      {items.map((item) => (
+        <div
+          key={item.currency}
+          className={cn(styles.balance, {
+            [styles.balance_negative]: item.balance < 0,
+          })}
+        >
+          {formatCurrency(item.balance, item.currency, item.minor_units)}
+        </div>
+      ))}
     </div>
   );
 };
@@ -50,7 +50,7 @@ const TransactionList = ({ transactions }: TransactionListProps) => {
                     {transaction.type}
                   </div>
                 </td>
-                <td className={styles.amount_cell}>{formatCurrency(transaction.amount)}</td>
+                <td className={styles.amount_cell}>{formatCurrency(transaction.amount, transaction.currency)}</td>
                 <td>
                   <div
                     className={cn(styles.status_badge, {
@@ -1,9 +1,13 @@
-export function formatCurrency(amount: number) {
+// Mirrors the minor units the backend stores amounts with.
+const MINOR_UNITS: Record<string, number> = { JPY: 0 };
+
+export function formatCurrency(amount: number, currency = 'IDR', minorUnits = MINOR_UNITS[currency] ?? 2) {
   return new Intl.NumberFormat('id-ID', {
     style: 'currency',
-    currency: 'IDR',
-    minimumFractionDigits: 0,
-  }).format(amount);
+    currency,
+    minimumFractionDigits: minorUnits,
+    maximumFractionDigits: minorUnits,
+  }).format(amount / 10 ** minorUnits);
 };
 
 